@@ -0,0 +1,136 @@
+package queue
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// BucketQueue is a priority queue for a small range of integer priorities 0 to levels-1, e.g.
+// the priority classes of a scheduler. It keeps one FIFO ring per priority level and a bitmap of
+// the non-empty levels, so Insert and Remove take O(1) for a fixed number of levels instead of
+// comparing priorities.
+// Like for Queue, elements with the same priority are removed oldest first.
+type BucketQueue[T any] struct {
+	order    Queuetype
+	lock     sync.Mutex
+	buckets  []bucketRing[T]
+	nonEmpty []uint64
+	len      int
+}
+
+// bucketRing is a growable FIFO ring buffer.
+type bucketRing[T any] struct {
+	buf  []T
+	head int
+	n    int
+}
+
+func (r *bucketRing[T]) push(c T) {
+	if r.n == len(r.buf) {
+		grown := make([]T, max(2*len(r.buf), minFifoHeadroom))
+		for i := 0; i < r.n; i++ {
+			grown[i] = r.buf[(r.head+i)%len(r.buf)]
+		}
+		r.buf, r.head = grown, 0
+	}
+	r.buf[(r.head+r.n)%len(r.buf)] = c
+	r.n++
+}
+
+func (r *bucketRing[T]) pop() T {
+	c := r.buf[r.head]
+	r.buf[r.head] = *new(T) // release the content for the GC
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+	return c
+}
+
+// NewBucketQueue builds a new, empty BucketQueue for the priorities 0 to levels-1. Only
+// PriorityHigh and PriorityLow are supported, for all other Queuetypes ErrInvalidQueueType is
+// returned. Returns ErrInvalidQueueLimit if levels < 1.
+func NewBucketQueue[T any](tp Queuetype, levels int) (*BucketQueue[T], error) {
+	if tp != PriorityHigh && tp != PriorityLow {
+		return nil, ErrInvalidQueueType
+	}
+	if levels < 1 {
+		return nil, ErrInvalidQueueLimit
+	}
+	return &BucketQueue[T]{
+		order:    tp,
+		buckets:  make([]bucketRing[T], levels),
+		nonEmpty: make([]uint64, (levels+63)/64),
+	}, nil
+}
+
+// Len returns the number of elements in the queue.
+func (b *BucketQueue[T]) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.len
+}
+
+// Insert inserts content with priority. Returns ErrInvalidPriority if priority is not one of the
+// levels of the queue.
+func (b *BucketQueue[T]) Insert(content T, priority int) error {
+	if priority < 0 || priority >= len(b.buckets) {
+		return ErrInvalidPriority
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.buckets[priority].push(content)
+	b.nonEmpty[priority/64] |= 1 << (priority % 64)
+	b.len++
+	return nil
+}
+
+// next returns the level that is removed from next. The queue must not be empty.
+// Does not lock b.
+func (b *BucketQueue[T]) next() int {
+	if b.order == PriorityHigh {
+		for w := len(b.nonEmpty) - 1; ; w-- {
+			if b.nonEmpty[w] != 0 {
+				return w*64 + bits.Len64(b.nonEmpty[w]) - 1
+			}
+		}
+	}
+	for w := 0; ; w++ {
+		if b.nonEmpty[w] != 0 {
+			return w*64 + bits.TrailingZeros64(b.nonEmpty[w])
+		}
+	}
+}
+
+// Remove pops the oldest content with the highest priority for PriorityHigh and the lowest for
+// PriorityLow queues.
+// If the queue is empty, an error is returned.
+func (b *BucketQueue[T]) Remove() (T, int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.len == 0 {
+		return *new(T), 0, ErrEmptyQueue
+	}
+	level := b.next()
+	c := b.buckets[level].pop()
+	if b.buckets[level].n == 0 {
+		b.nonEmpty[level/64] &^= 1 << (level % 64)
+	}
+	b.len--
+	return c, level, nil
+}
+
+// PeekElem returns the priority and content that would be returned on a call to Remove().
+// Returns an error of type ErrEmptyQueue when the queue is empty.
+func (b *BucketQueue[T]) PeekElem() (int, T, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.len == 0 {
+		return 0, *new(T), ErrEmptyQueue
+	}
+	level := b.next()
+	r := &b.buckets[level]
+	return level, r.buf[r.head], nil
+}
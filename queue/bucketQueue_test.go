@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestBucketQueue(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		tp   Queuetype
+		want []int
+	}{
+		{PriorityHigh, []int{200, 200, 70, 3, 3, 3, 0}},
+		{PriorityLow, []int{0, 3, 3, 3, 70, 200, 200}},
+	} {
+		b, err := NewBucketQueue[int](c.tp, 256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the content is the insertion index, so that the age can be checked.
+		priorities := []int{3, 200, 0, 3, 70, 200, 3}
+		for i, p := range priorities {
+			if err := b.Insert(i, p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := b.Insert(0, 256); !errors.Is(err, ErrInvalidPriority) {
+			t.Errorf("queuetype %v: expected %v, got %v", c.tp, ErrInvalidPriority, err)
+		}
+
+		last := map[int]int{}
+		for _, want := range c.want {
+			p, peeked, err := b.PeekElem()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, priority, err := b.Remove()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if priority != want || p != want || peeked != got {
+				t.Errorf("queuetype %v: expected priority %d, got %d (peeked %d)", c.tp, want, priority, p)
+			}
+			if prev, ok := last[priority]; ok && got < prev {
+				t.Errorf("queuetype %v: %d removed after younger %d", c.tp, prev, got)
+			}
+			last[priority] = got
+		}
+		if _, _, err := b.Remove(); !errors.Is(err, ErrEmptyQueue) {
+			t.Errorf("queuetype %v: expected %v, got %v", c.tp, ErrEmptyQueue, err)
+		}
+	}
+
+	if _, err := NewBucketQueue[int](Fifo, 8); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+}
+
+func TestBucketQueueGrowsRing(t *testing.T) {
+	t.Parallel()
+	b, err := NewBucketQueue[int](PriorityLow, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// interleave so that the ring wraps around before it grows.
+	next := 0
+	for i := 0; i < 100; i++ {
+		if err := b.Insert(i, 0); err != nil {
+			t.Fatal(err)
+		}
+		if i%3 == 0 {
+			if c, _, _ := b.Remove(); c != next {
+				t.Fatalf("expected %d, got %d", next, c)
+			}
+			next++
+		}
+	}
+	for b.Len() > 0 {
+		if c, _, _ := b.Remove(); c != next {
+			t.Fatalf("expected %d, got %d", next, c)
+		}
+		next++
+	}
+}
@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// AsChannels exposes q as an unbounded channel: the contents sent on in are inserted into q and
+// the contents are received from out in the removal order of q, so that for example a Comparator
+// queue acts as a priority-ordered channel. Two goroutines pump the contents through q, senders on
+// in only wait for the insertion and never for a receiver.
+// The contents are inserted as BaseElements, so priority queues order them by insertion age. If
+// q caps its length like FifoLimited, senders wait until there is room as with BlockingInsert.
+// Contents q rejects, for example because it is closed, are dropped.
+//
+// Closing in ends the insertions. stop ends both pumps and waits for them, out is closed
+// afterwards, as it is once q is closed and drained. Nothing must be sent on in after stop. The
+// elements left in q stay there, a content that was removed but not yet received is inserted
+// again as a PriorityElement.
+// q must not be a queue of NewQueueUnsecure, whose blocking removals can't be woken.
+func AsChannels[T any](q *Queue[T]) (in chan<- T, out <-chan T, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	inCh := make(chan T)
+	outCh := make(chan T)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		pumpIn(ctx, q, inCh)
+	}()
+	go func() {
+		defer wg.Done()
+		defer close(outCh)
+		pumpOut(ctx, q, outCh)
+	}()
+
+	var once sync.Once
+	return inCh, outCh, func() {
+		once.Do(func() {
+			cancel()
+			wg.Wait()
+		})
+	}
+}
+
+// pumpIn inserts the contents received on in into q until in is closed or ctx is done.
+func pumpIn[T any](ctx context.Context, q *Queue[T], in <-chan T) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c, ok := <-in:
+			if !ok {
+				return
+			}
+			if err := q.BlockingInsert(ctx, NewBaseElement(c)); errors.Is(err, context.Canceled) {
+				return
+			}
+		}
+	}
+}
+
+// pumpOut sends the contents removed from q on out until q is closed and drained or ctx is done.
+func pumpOut[T any](ctx context.Context, q *Queue[T], out chan<- T) {
+	for {
+		c, priority, err := q.BlockingRemove(ctx)
+		if err != nil {
+			return
+		}
+		select {
+		case out <- c:
+		case <-ctx.Done():
+			_ = q.Insert(NewPriorityElement(c, priority))
+			return
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"cmp"
+	"testing"
+)
+
+func TestAsChannels(t *testing.T) {
+	t.Parallel()
+	q := NewQueueFunc(cmp.Compare[int])
+	for _, c := range []int{5, 1, 4, 2, 3} {
+		if err := q.Insert(NewBaseElement(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in, out, stop := AsChannels(q)
+	for want := 1; want <= 2; want++ {
+		if got := <-out; got != want {
+			t.Errorf("expected %d, got %d", want, got)
+		}
+	}
+	stop()
+	stop()
+	if _, ok := <-out; ok {
+		t.Error("expected out to be closed")
+	}
+	// the content the pump held back is in the queue again.
+	if got, want := drain(t, q), []int{3, 4, 5}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	in, out, stop = AsChannels(q)
+	defer stop()
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	close(in)
+	for want := 0; want < 3; want++ {
+		if got := <-out; got != want {
+			t.Errorf("expected %d, got %d", want, got)
+		}
+	}
+	q.Close()
+	if _, ok := <-out; ok {
+		t.Error("expected out to be closed once the queue is closed and drained")
+	}
+}
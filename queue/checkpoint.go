@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// SaveToFile writes the limit and all elements of the queue to the file at path, using codec for
+// the element contents. The file is written to a temporary file in the same directory first and
+// then renamed to path, so path either holds the previous or the new checkpoint, never a partial
+// one.
+// Locks q while encoding.
+func (q *Queue[T]) SaveToFile(path string, codec ContentCodec[T]) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return errors.Wrap(err, "creating temporary checkpoint file")
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+
+	q.lock.RLock()
+	err = q.encodeBinary(tmp, codec)
+	q.lock.RUnlock()
+	if err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "encoding queue")
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "syncing checkpoint file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "closing checkpoint file")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), path), "replacing checkpoint file")
+}
+
+// LoadFromFile builds a new queue of Queuetype tp from a checkpoint written by SaveToFile, using
+// codec for the element contents. The elements are inserted in their original insertion order, so
+// a queue of the same Queuetype has the same removal order as the saved one. The limit of the saved
+// queue is restored.
+// Returns an error of type ErrCorruptData if the file is malformed.
+func LoadFromFile[T any](path string, tp Queuetype, codec ContentCodec[T]) (*Queue[T], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening checkpoint file")
+	}
+	defer f.Close()
+
+	decoded, err := decodeBinary(f, codec)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding checkpoint")
+	}
+
+	q, err := NewQueue[T](tp)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.SetLimit(decoded.limit); err != nil {
+		return nil, errors.Wrap(err, "restoring limit")
+	}
+	for i, elem := range decoded.elems {
+		if err := q.Insert(elem); err != nil {
+			return nil, errors.Wrapf(err, "inserting element %d", i)
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,132 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// intCodec encodes ints as decimal strings.
+type intCodec struct{}
+
+func (intCodec) Encode(c int) ([]byte, error) {
+	return []byte(strconv.Itoa(c)), nil
+}
+
+func (intCodec) Decode(data []byte) (int, error) {
+	return strconv.Atoi(string(data))
+}
+
+func TestSaveLoadFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh, PriorityLow, FifoLimited} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := q.SetLimit(7); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 10; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64(i%3))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, _, _ = q.Remove()
+
+		path := filepath.Join(dir, "checkpoint")
+		if err := q.SaveToFile(path, intCodec{}); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadFromFile(path, tp, intCodec{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if loaded.Len() != q.Len() {
+			t.Fatalf("queuetype %v: expected length %d, got %d", tp, q.Len(), loaded.Len())
+		}
+		for q.Len() > 0 {
+			wantC, wantP, _ := q.Remove()
+			gotC, gotP, err := loaded.Remove()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotC != wantC || gotP != wantP {
+				t.Errorf("queuetype %v: expected (%d, %v), got (%d, %v)", tp, wantC, wantP, gotC, gotP)
+			}
+		}
+		if loaded.maxnumElements != 7 {
+			t.Errorf("queuetype %v: expected limit 7, got %d", tp, loaded.maxnumElements)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the checkpoint file to remain, got %d files", len(entries))
+	}
+}
+
+func TestSaveLoadFileSubPriority(t *testing.T) {
+	t.Parallel()
+	q, _ := NewQueue[int](PriorityHigh)
+	for _, elem := range []Element[int]{
+		NewPriorityElement2(1, 1, 1),
+		NewPriorityElement2(2, 1, 3),
+		NewPriorityElement2(3, 1, 2),
+		NewPriorityElement(4, 2),
+		NewBaseElement(5),
+	} {
+		if err := q.Insert(elem); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	if err := q.SaveToFile(path, intCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFromFile(path, PriorityHigh, intCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := drain(t, loaded), []int{4, 2, 3, 1, 5}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestLoadFileCorrupt(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkpoint")
+	q := fifoOf(t, 1, 2, 3)
+	if err := q.SaveToFile(path, intCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := append([]byte(nil), data...)
+	flipped[len(flipped)/2] ^= 0xff
+	for name, corrupt := range map[string][]byte{
+		"flipped":   flipped,
+		"truncated": data[:len(data)-3],
+		"empty":     {},
+	} {
+		if err := os.WriteFile(path, corrupt, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFromFile(path, Fifo, intCodec{}); !errors.Is(err, ErrCorruptData) {
+			t.Errorf("%s: expected %v, got %v", name, ErrCorruptData, err)
+		}
+	}
+}
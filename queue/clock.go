@@ -0,0 +1,43 @@
+package queue
+
+import "time"
+
+// Clock is the source of time for the time based types and functions of this package.
+// It can be replaced to control the passing of time, e.g. in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current time on the returned
+	// channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// now returns the current time according to the clock of q.
+// Does not lock q.
+func (q *Queue[T]) now() time.Time {
+	if q.clock == nil {
+		return time.Now()
+	}
+	return q.clock.Now()
+}
+
+// after waits for d to elapse on the clock of q like Clock.After.
+// Does not lock q.
+func (q *Queue[T]) after(d time.Duration) <-chan time.Time {
+	if q.clock == nil {
+		return time.After(d)
+	}
+	return q.clock.After(d)
+}
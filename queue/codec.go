@@ -0,0 +1,212 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// ContentCodec converts element contents to and from bytes for the binary encoding of a queue.
+type ContentCodec[T any] interface {
+	Encode(content T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// binaryMagic starts every binary encoded queue, followed by the format version.
+var binaryMagic = [4]byte{'D', 'A', 'Q', 'U'}
+
+// binaryVersion is the version encodeBinary writes. Version 2 added binarySubPriorityElement,
+// decodeBinary still reads version 1.
+const binaryVersion = 2
+
+const (
+	// binaryBaseElement marks an element that is decoded as a BaseElement.
+	binaryBaseElement byte = iota
+	// binaryPriorityElement marks an element that is decoded as a PriorityElement.
+	binaryPriorityElement
+	// binarySubPriorityElement marks an element that is decoded as a SubPriorityElement. Its
+	// sub-priority follows the priority.
+	binarySubPriorityElement
+)
+
+// encodedQueue is the decoded form of a binary encoded queue.
+type encodedQueue[T any] struct {
+	order Queuetype
+	limit int
+	// elems are in insertion order.
+	elems []Element[T]
+}
+
+// encodeBinary writes the Queuetype, limit and all elements of q to w, followed by a CRC32 checksum.
+// The elements are written in insertion order, so reinserting them in that order rebuilds the same
+// removal order.
+// Does not lock q.
+func (q *Queue[T]) encodeBinary(w io.Writer, codec ContentCodec[T]) error {
+	elems := q.insertionOrder()
+
+	var buf bytes.Buffer
+	buf.Write(binaryMagic[:])
+	buf.WriteByte(binaryVersion)
+	buf.Write(binary.AppendUvarint(nil, uint64(q.order)))
+	buf.Write(binary.AppendUvarint(nil, uint64(q.maxnumElements)))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(elems))))
+	for i, elem := range elems {
+		data, err := codec.Encode(elem.Content())
+		if err != nil {
+			return errors.Wrapf(err, "encoding element %d", i)
+		}
+
+		kind := binaryPriorityElement
+		if _, ok := elem.(*BaseElement[T]); ok {
+			kind = binaryBaseElement
+		} else if _, ok := elem.(subPrioritized); ok {
+			kind = binarySubPriorityElement
+		}
+		buf.WriteByte(kind)
+		buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(elem.Priority())))
+		if kind == binarySubPriorityElement {
+			buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(subPriorityOf(elem))))
+		}
+		buf.Write(binary.AppendUvarint(nil, uint64(len(data))))
+		buf.Write(data)
+	}
+	buf.Write(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(buf.Bytes())))
+
+	_, err := w.Write(buf.Bytes())
+	return errors.Wrap(err, "writing encoded queue")
+}
+
+// insertionOrder returns the elements of q sorted by their insertion sequence numbers, so that
+// inserting them in that order into a queue of the same Queuetype rebuilds the removal order.
+// Does not lock q.
+func (q *Queue[T]) insertionOrder() []Element[T] {
+	elems := slices.Clone(q.queueSlice)
+	slices.SortStableFunc(elems, func(a, b Element[T]) int {
+		sa, sb := sequenceOf(a), sequenceOf(b)
+		switch {
+		case sa < sb:
+			return -1
+		case sa > sb:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return elems
+}
+
+// restore replaces the Queuetype, limit and contents of q with decoded ones like Replace. elems
+// must be in insertion order. The comparator, tie-breaker and overflow policy of q are kept.
+// Returns an error of type ErrCorruptData if order or limit is invalid and ErrInvalidQueueType if
+// order is Comparator but q has no comparator.
+// Locks q.
+func (q *Queue[T]) restore(order Queuetype, limit int, elems []Element[T]) error {
+	if order < 0 || order >= numQueuetypes {
+		return errors.Wrap(ErrCorruptData, "invalid queuetype")
+	}
+	if limit < 0 {
+		return errors.Wrap(ErrCorruptData, "invalid limit")
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if order == Comparator && q.cmp == nil && q.less == nil {
+		return errors.Wrap(ErrInvalidQueueType, "restoring a Comparator queue without comparator")
+	}
+	q.order = order
+	q.maxnumElements = limit
+	q.replace(elems)
+	return nil
+}
+
+// decodeBinary reads a queue written by encodeBinary from r.
+// Returns an error of type ErrCorruptData if the data is malformed or fails the checksum.
+func decodeBinary[T any](r io.Reader, codec ContentCodec[T]) (*encodedQueue[T], error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading encoded queue")
+	}
+	if len(data) < len(binaryMagic)+1+4 {
+		return nil, errors.Wrap(ErrCorruptData, "encoded queue is too short")
+	}
+	payload, sum := data[:len(data)-4], data[len(data)-4:]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(sum) {
+		return nil, errors.Wrap(ErrCorruptData, "checksum mismatch")
+	}
+	if !bytes.Equal(payload[:len(binaryMagic)], binaryMagic[:]) {
+		return nil, errors.Wrap(ErrCorruptData, "unknown format")
+	}
+	if v := payload[len(binaryMagic)]; v < 1 || v > binaryVersion {
+		return nil, errors.Wrapf(ErrCorruptData, "unknown version %d", v)
+	}
+
+	br := bufio.NewReader(bytes.NewReader(payload[len(binaryMagic)+1:]))
+	var header [3]uint64
+	for i := range header {
+		if header[i], err = binary.ReadUvarint(br); err != nil {
+			return nil, errors.Wrap(ErrCorruptData, "reading header")
+		}
+	}
+	if header[0] >= numQueuetypes {
+		return nil, errors.Wrap(ErrCorruptData, "invalid queuetype")
+	}
+
+	ret := &encodedQueue[T]{
+		order: Queuetype(header[0]),
+		limit: int(header[1]),
+	}
+	for i := uint64(0); i < header[2]; i++ {
+		kind, err := br.ReadByte()
+		if err != nil {
+			return nil, errors.Wrapf(ErrCorruptData, "reading element %d", i)
+		}
+		var bits [8]byte
+		if _, err := io.ReadFull(br, bits[:]); err != nil {
+			return nil, errors.Wrapf(ErrCorruptData, "reading priority of element %d", i)
+		}
+		var subBits [8]byte
+		if kind == binarySubPriorityElement {
+			if _, err := io.ReadFull(br, subBits[:]); err != nil {
+				return nil, errors.Wrapf(ErrCorruptData, "reading sub-priority of element %d", i)
+			}
+		}
+		n, err := binary.ReadUvarint(br)
+		if err != nil || n > uint64(len(payload)) {
+			return nil, errors.Wrapf(ErrCorruptData, "reading content length of element %d", i)
+		}
+		content := make([]byte, n)
+		if _, err := io.ReadFull(br, content); err != nil {
+			return nil, errors.Wrapf(ErrCorruptData, "reading content of element %d", i)
+		}
+
+		c, err := codec.Decode(content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding element %d", i)
+		}
+		switch kind {
+		case binaryBaseElement:
+			ret.elems = append(ret.elems, NewBaseElement(c))
+		case binaryPriorityElement:
+			priority := math.Float64frombits(binary.LittleEndian.Uint64(bits[:]))
+			ret.elems = append(ret.elems, NewPriorityElement(c, priority))
+		case binarySubPriorityElement:
+			priority := math.Float64frombits(binary.LittleEndian.Uint64(bits[:]))
+			subPriority := math.Float64frombits(binary.LittleEndian.Uint64(subBits[:]))
+			ret.elems = append(ret.elems, NewPriorityElement2(c, priority, subPriority))
+		default:
+			return nil, errors.Wrapf(ErrCorruptData, "unknown kind of element %d", i)
+		}
+	}
+	if _, err := br.ReadByte(); !errors.Is(err, io.EOF) {
+		return nil, errors.Wrap(ErrCorruptData, "trailing data")
+	}
+
+	return ret, nil
+}
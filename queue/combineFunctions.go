@@ -0,0 +1,217 @@
+package queue
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// pairLock serializes the operations that lock two queues, so that two of them can't lock the same
+// pair of queues in opposite order and deadlock.
+var pairLock sync.Mutex
+
+// lockPair locks q and other and returns the function unlocking them again.
+func lockPair[T any](q, other *Queue[T]) (unlock func()) {
+	pairLock.Lock()
+	q.lock.Lock()
+	other.lock.Lock()
+	return func() {
+		other.lock.Unlock()
+		q.lock.Unlock()
+		pairLock.Unlock()
+	}
+}
+
+// Interleave builds a new Fifo queue by removing one element from each of qs in turn
+// (round-robin) until all of them are drained. The elements of each source keep their removal
+// order; exhausted sources are skipped.
+// The sources are drained, each removal locks its source.
+func Interleave[T any](qs ...*Queue[T]) (*Queue[T], error) {
+	newQueue, err := NewQueue[T](Fifo)
+	if err != nil {
+		return nil, errors.Wrap(err, "building interleaved queue")
+	}
+
+	active := append([]*Queue[T](nil), qs...)
+	for len(active) > 0 {
+		remaining := active[:0]
+		for i, q := range active {
+			elem, err := q.RemoveElement()
+			if errors.Is(err, ErrEmptyQueue) {
+				continue
+			} else if err != nil {
+				return nil, errors.Wrapf(err, "removing element from queue %d", i)
+			}
+			if err := newQueue.Insert(elem); err != nil {
+				return nil, errors.Wrap(err, "inserting interleaved element")
+			}
+			remaining = append(remaining, q)
+		}
+		active = remaining
+	}
+
+	return newQueue, nil
+}
+
+// Merge moves all elements of other into q and leaves other empty. Both queues must have the same
+// Queuetype. The elements of other are treated as inserted after the elements of q, in their
+// original insertion order, so among elements with the same main ordering property those of q are
+// removed first and both keep their relative age. Comparator queues order by the comparator of q.
+// Like in Concat, the elements of other are copied, so that clones, splits and snapshots of other
+// that share them are not changed.
+// Priority queues are merged in a single O(n + k) pass for k elements in other, unless one of them
+// has a tie-breaker or they are Comparator queues, then the elements of other are sorted first.
+// FifoLimited and LRU queues over their limit drop their oldest elements afterwards.
+// Locks q and other. Returns ErrInvalidQueueType if the Queuetypes differ, ErrQueueClosed if q is
+// closed and ErrSameQueue if other is q.
+func (q *Queue[T]) Merge(other *Queue[T]) error {
+	if other == q {
+		return ErrSameQueue
+	}
+	unlock := lockPair(q, other)
+	defer unlock()
+
+	if q.order != other.order {
+		return errors.Wrap(ErrInvalidQueueType, "merging queues of different Queuetypes")
+	}
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	moved := other.queueSlice
+	for _, elem := range moved {
+		other.hookRemoved(elem)
+	}
+	q.adopt(moved, other.seq, q.sameOrder(other))
+	other.queueSlice = make([]Element[T], 0)
+	other.fifoBuf = nil
+	other.numElements = 0
+	other.reindex()
+	other.counters.removes.Add(uint64(len(moved)))
+	other.notifyRemoved()
+	return nil
+}
+
+// Concat returns a new queue holding the elements of q followed by the elements of other as if
+// other was merged into a clone of q with Merge. q and other are not changed. The elements of other
+// are copied, since their insertion sequence numbers change, and become PriorityElements unless
+// they are elements of this package. The elements of q are shared with the new queue like in Clone.
+// Locks q and other. Returns ErrInvalidQueueType if the Queuetypes differ.
+func (q *Queue[T]) Concat(other *Queue[T]) (*Queue[T], error) {
+	var unlock func()
+	if other == q {
+		q.lock.Lock()
+		unlock = q.lock.Unlock
+	} else {
+		unlock = lockPair(q, other)
+	}
+	defer unlock()
+
+	if q.order != other.order {
+		return nil, errors.Wrap(ErrInvalidQueueType, "concatenating queues of different Queuetypes")
+	}
+
+	newQueue := q.cloneUnsecure()
+	newQueue.adopt(other.queueSlice, other.seq, q.sameOrder(other))
+	return newQueue, nil
+}
+
+// sameOrder reports whether the slices of q and other are sorted by the same removalCmp.
+// Does not lock q or other.
+func (q *Queue[T]) sameOrder(other *Queue[T]) bool {
+	// comparators and tie-breakers can't be compared.
+	return q.order != Comparator && q.tieBreak == nil && other.tieBreak == nil
+}
+
+// adopt inserts copies of the elements of another queue, whose last insertion sequence number is
+// seq, as if they were inserted after all elements of q in their original order. If sorted is set,
+// elems are ordered like queueSlice already, otherwise they are sorted first. The copies are
+// restamped, elems are not changed, since they may be shared with clones of the other queue.
+// Does not lock q.
+func (q *Queue[T]) adopt(elems []Element[T], seq uint64, sorted bool) {
+	copies := make([]Element[T], len(elems))
+	for i, elem := range elems {
+		c := copyElement(elem)
+		// shifting the sequence numbers keeps the relative age of elems.
+		if s, ok := c.(sequenced); ok {
+			s.setSequence(q.seq + s.sequence())
+		}
+		copies[i] = c
+	}
+	elems = copies
+	q.seq += seq
+
+	capBefore := q.backingCap()
+	if sorted {
+		q.merge(elems)
+	} else {
+		q.mergeSorted(elems)
+	}
+	for _, elem := range elems {
+		q.entered(elem)
+	}
+	q.numElements += len(elems)
+	q.countGrow(capBefore)
+	q.counters.inserts.Add(uint64(len(elems)))
+	q.trimToLimit()
+	q.notifyInserted()
+}
+
+// copyElement returns a copy of elem that shares its content.
+func copyElement[T any](elem Element[T]) Element[T] {
+	switch e := elem.(type) {
+	case *BaseElement[T]:
+		c := *e
+		return &c
+	case *PriorityElement[T]:
+		c := *e
+		return &c
+	case *SubPriorityElement[T]:
+		c := *e
+		return &c
+	case *ExpiringElement[T]:
+		c := *e
+		return &c
+	case *DelayedElement[T]:
+		c := *e
+		return &c
+	default:
+		c := NewPriorityElement(elem.Content(), elem.Priority())
+		c.seq = sequenceOf(elem)
+		return c
+	}
+}
+
+// SplitAt returns a queue with the first n elements of q in removal order and a queue with the
+// rest, for example to shard work among workers. Both keep the Queuetype, configuration and order
+// of q and share the elements with q like Clone. q is not changed.
+// Returns an *IndexError, which matches ErrIndexOutOfBounds, if n < 0 or n > q.Len().
+func (q *Queue[T]) SplitAt(n int) (*Queue[T], *Queue[T], error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if n < 0 || n > q.numElements {
+		return nil, nil, &IndexError{Index: n, Len: q.numElements}
+	}
+	// the first elements in removal order are at the end of the slice.
+	split := q.numElements - n
+	return q.cloneWith(q.queueSlice[split:]), q.cloneWith(q.queueSlice[:split]), nil
+}
+
+// Partition returns a queue with the elements of q whose content matches pred and a queue with the
+// rest in a single O(n) pass. Both keep the Queuetype, configuration and order of q and share the
+// elements with q like Clone. q is not changed.
+func (q *Queue[T]) Partition(pred func(T) bool) (matching, rest *Queue[T]) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	var in, out []Element[T]
+	for _, elem := range q.queueSlice {
+		if pred(elem.Content()) {
+			in = append(in, elem)
+		} else {
+			out = append(out, elem)
+		}
+	}
+	return q.cloneWith(in), q.cloneWith(out)
+}
@@ -0,0 +1,272 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func fifoOf(t *testing.T, contents ...int) *Queue[int] {
+	t.Helper()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range contents {
+		if err := q.Insert(NewBaseElement(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return q
+}
+
+// drain removes all elements of q and returns their contents in removal order.
+func drain[T any](t *testing.T, q *Queue[T]) []T {
+	t.Helper()
+	var ret []T
+	for q.Len() > 0 {
+		c, _, err := q.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+func equalContents[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestInterleave(t *testing.T) {
+	t.Parallel()
+	a := fifoOf(t, 1, 2, 3, 4)
+	b := fifoOf(t, 10)
+	c := fifoOf(t)
+	d := fifoOf(t, 20, 21)
+
+	q, err := Interleave(a, b, c, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{1, 10, 20, 2, 21, 3, 4}
+	if got := drain(t, q); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for i, src := range []*Queue[int]{a, b, c, d} {
+		if src.Len() != 0 {
+			t.Errorf("expected source %d to be drained, got length %d", i, src.Len())
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh, PriorityLow} {
+		a, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := NewQueue[int](tp)
+		// the reference gets the elements of a and then those of b.
+		ref, _ := NewQueue[int](tp)
+		for i := 0; i < 6; i++ {
+			if err := a.Insert(NewPriorityElement(i, float64(i%3))); err != nil {
+				t.Fatal(err)
+			}
+			if err := ref.Insert(NewPriorityElement(i, float64(i%3))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 10; i < 15; i++ {
+			if err := b.Insert(NewPriorityElement(i, float64(i%3))); err != nil {
+				t.Fatal(err)
+			}
+			if err := ref.Insert(NewPriorityElement(i, float64(i%3))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		concat, err := a.Concat(b)
+		if err != nil {
+			t.Fatalf("queuetype %v: %v", tp, err)
+		}
+		if err := a.Merge(b); err != nil {
+			t.Fatalf("queuetype %v: %v", tp, err)
+		}
+		if b.Len() != 0 {
+			t.Errorf("queuetype %v: expected the merged queue to be empty, got length %d", tp, b.Len())
+		}
+
+		want := drain(t, ref)
+		if got := drain(t, concat); !equalContents(got, want) {
+			t.Errorf("queuetype %v: concat expected %v, got %v", tp, want, got)
+		}
+		if got := drain(t, a); !equalContents(got, want) {
+			t.Errorf("queuetype %v: merge expected %v, got %v", tp, want, got)
+		}
+	}
+}
+
+func TestMergeLimited(t *testing.T) {
+	t.Parallel()
+	a, err := NewQueue[int](FifoLimited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = a.SetLimit(3)
+	b, _ := NewQueue[int](FifoLimited)
+	for i := 0; i < 2; i++ {
+		_ = a.Insert(NewBaseElement(i))
+		_ = b.Insert(NewBaseElement(10 + i))
+	}
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := drain(t, a), []int{1, 10, 11}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if m := a.Metrics(); m.Evictions != 1 {
+		t.Errorf("expected 1 eviction, got %d", m.Evictions)
+	}
+}
+
+func TestMergeKeepsClones(t *testing.T) {
+	t.Parallel()
+	a, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewQueue[int](PriorityHigh)
+	for i := 0; i < 4; i++ {
+		_ = a.Insert(NewPriorityElement(i, 1))
+	}
+	for i := 10; i < 12; i++ {
+		_ = b.Insert(NewPriorityElement(i, 1))
+	}
+	clone := b.Clone()
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	// the clone still sees the old ages of its elements, so a re-sort keeps them in front of the
+	// elements inserted afterwards.
+	_ = clone.Insert(NewPriorityElement(12, 1))
+	clone.RebuildInvariant()
+	if got, want := drain(t, clone), []int{10, 11, 12}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got, want := drain(t, a), []int{0, 1, 2, 3, 10, 11}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	t.Parallel()
+	a := fifoOf(t, 1)
+	b, err := NewQueue[int](Lifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Merge(b); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+	if _, err := a.Concat(b); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+	if err := a.Merge(a); !errors.Is(err, ErrSameQueue) {
+		t.Errorf("expected %v, got %v", ErrSameQueue, err)
+	}
+
+	c := fifoOf(t, 2)
+	a.Close()
+	if err := a.Merge(c); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+	if c.Len() != 1 {
+		t.Errorf("expected the failed merge to keep the elements, got length %d", c.Len())
+	}
+}
+
+func TestConcatComparator(t *testing.T) {
+	t.Parallel()
+	a := NewQueueFunc(func(x, y int) int { return x - y })
+	b := NewQueueFunc(func(x, y int) int { return y - x })
+	for _, c := range []int{5, 1, 3} {
+		_ = a.Insert(NewBaseElement(c))
+		_ = b.Insert(NewBaseElement(c + 1))
+	}
+
+	q, err := a.Concat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := drain(t, q), []int{1, 2, 3, 4, 5, 6}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if b.Len() != 3 {
+		t.Errorf("expected concat to keep the source, got length %d", b.Len())
+	}
+}
+
+func TestSplitAt(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 6; i++ {
+		if err := q.Insert(NewPriorityElement(i, float64(i%2))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first, rest, err := q.SplitAt(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := drain(t, first), []int{1, 3}; !equalContents(got, want) {
+		t.Errorf("expected first %v, got %v", want, got)
+	}
+	// new elements keep being removed after older ones with the same priority.
+	if err := rest.Insert(NewPriorityElement(10, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := drain(t, rest), []int{5, 10, 0, 2, 4}; !equalContents(got, want) {
+		t.Errorf("expected rest %v, got %v", want, got)
+	}
+	if q.Len() != 6 {
+		t.Errorf("expected q to be unchanged, got length %d", q.Len())
+	}
+
+	for _, n := range []int{-1, 7} {
+		if _, _, err := q.SplitAt(n); !errors.Is(err, ErrIndexOutOfBounds) {
+			t.Errorf("n %d: expected %v, got %v", n, ErrIndexOutOfBounds, err)
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+	t.Parallel()
+	q := fifoOf(t, 0, 1, 2, 3, 4, 5)
+	even, odd := q.Partition(func(c int) bool { return c%2 == 0 })
+	if got, want := drain(t, even), []int{0, 2, 4}; !equalContents(got, want) {
+		t.Errorf("expected matching %v, got %v", want, got)
+	}
+	if got, want := drain(t, odd), []int{1, 3, 5}; !equalContents(got, want) {
+		t.Errorf("expected rest %v, got %v", want, got)
+	}
+	if q.Len() != 6 {
+		t.Errorf("expected q to be unchanged, got length %d", q.Len())
+	}
+}
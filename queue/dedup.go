@@ -0,0 +1,120 @@
+package queue
+
+// DedupMode determines what Insert does with an element whose key is already in a queue with
+// deduplication. See SetDedup.
+type DedupMode int
+
+const (
+	// DedupReject makes Insert fail with ErrDuplicate.
+	DedupReject DedupMode = iota
+
+	// DedupReplace removes the element with the same key and inserts the new one as if it was
+	// freshly inserted.
+	DedupReplace
+
+	numDedupModes = 2
+)
+
+// dedupIndex maps the keys of the elements of a queue to the elements.
+type dedupIndex[T any] struct {
+	key   func(T) any
+	mode  DedupMode
+	elems map[any]Element[T]
+}
+
+// SetDedup enables the deduplication of the elements of q by the key that key returns for their
+// contents. The queue keeps an index of the keys, so Insert checks for a duplicate in O(1) and
+// applies mode to it. Replacing an element finds it like ElementHandle.SetPriority.
+// Insert, InsertN, InsertAll, InsertHandle and BlockingInsert check for duplicates. Append,
+// AppendAll, Replace, Merge and Concat keep the index up to date, but do not check, so duplicates
+// they add are only found by key through the element added last.
+// A nil key disables the deduplication. Returns ErrInvalidDedupMode if mode is unknown.
+// Locks q.
+func SetDedup[T any, K comparable](q *Queue[T], key func(T) K, mode DedupMode) error {
+	if mode < 0 || mode >= numDedupModes {
+		return ErrInvalidDedupMode
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if key == nil {
+		q.dedup = nil
+		return nil
+	}
+	q.dedup = &dedupIndex[T]{
+		key:  func(c T) any { return key(c) },
+		mode: mode,
+	}
+	q.reindex()
+	return nil
+}
+
+// ContainsKey reports in O(1) whether q holds an element whose content has key according to the
+// key function of SetDedup. Always returns false if q has no deduplication.
+// Locks q.
+func ContainsKey[T any, K comparable](q *Queue[T], key K) bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if q.dedup == nil {
+		return false
+	}
+	_, ok := q.dedup.elems[key]
+	return ok
+}
+
+// checkDuplicate applies the deduplication mode to an element with the key of elem, before elem
+// is inserted.
+// Does not lock q.
+func (q *Queue[T]) checkDuplicate(elem Element[T]) error {
+	if q.dedup == nil {
+		return nil
+	}
+	existing, ok := q.dedup.elems[q.dedup.key(elem.Content())]
+	if !ok {
+		return nil
+	}
+	if q.dedup.mode == DedupReject {
+		return ErrDuplicate
+	}
+	if i := q.indexOf(existing); i >= 0 {
+		if _, err := q.remove(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// track adds elem to the index of q once it entered the queue.
+// Does not lock q.
+func (q *Queue[T]) track(elem Element[T]) {
+	if q.dedup != nil {
+		q.dedup.elems[q.dedup.key(elem.Content())] = elem
+	}
+}
+
+// untrack removes elem from the index of q once it left the queue.
+// Does not lock q.
+func (q *Queue[T]) untrack(elem Element[T]) {
+	if q.dedup == nil {
+		return
+	}
+	key := q.dedup.key(elem.Content())
+	// a duplicate that was added without check may have taken over the key.
+	if q.dedup.elems[key] == elem {
+		delete(q.dedup.elems, key)
+	}
+}
+
+// reindex rebuilds the index of q from its elements, e.g. after their contents changed.
+// Does not lock q.
+func (q *Queue[T]) reindex() {
+	if q.dedup == nil {
+		return
+	}
+	q.dedup.elems = make(map[any]Element[T], q.numElements)
+	// the oldest elements come first, so that the youngest duplicate owns the key.
+	for _, elem := range q.insertionOrder() {
+		q.track(elem)
+	}
+}
@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestDedupReject(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, PriorityHigh} {
+		q, err := NewQueue[string](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := SetDedup(q, strings.ToLower, DedupReject); err != nil {
+			t.Fatal(err)
+		}
+		for i, c := range []string{"a", "b", "c"} {
+			if err := q.Insert(NewPriorityElement(c, float64(i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := q.Insert(NewPriorityElement("B", 5)); !errors.Is(err, ErrDuplicate) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrDuplicate, err)
+		}
+		if !ContainsKey(q, "b") || ContainsKey(q, "d") {
+			t.Errorf("queuetype %v: wrong keys in index", tp)
+		}
+
+		// removing an element frees its key.
+		c, _, err := q.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ContainsKey(q, c) {
+			t.Errorf("queuetype %v: key %q still indexed after removal", tp, c)
+		}
+		if err := q.Insert(NewPriorityElement(strings.ToUpper(c), 0)); err != nil {
+			t.Errorf("queuetype %v: %v", tp, err)
+		}
+		if q.Len() != 3 {
+			t.Errorf("queuetype %v: expected length 3, got %d", tp, q.Len())
+		}
+	}
+}
+
+func TestDedupReplace(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[string](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetDedup(q, func(c string) byte { return c[0] }, DedupReplace); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		content  string
+		priority float64
+	}{{"a1", 3}, {"b1", 2}, {"c1", 1}, {"a2", 0}, {"c2", 4}} {
+		if err := q.Insert(NewPriorityElement(c.content, c.priority)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := drain(t, q), []string{"c2", "b1", "a2"}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSetDedupIndexesExistingElements(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.InsertAll([]Element[int]{NewBaseElement(1), NewBaseElement(2)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetDedup(q, func(c int) int { return c }, DedupMode(numDedupModes)); !errors.Is(err, ErrInvalidDedupMode) {
+		t.Errorf("expected %v, got %v", ErrInvalidDedupMode, err)
+	}
+	if err := SetDedup(q, func(c int) int { return c }, DedupReject); err != nil {
+		t.Fatal(err)
+	}
+
+	err = q.InsertAll([]Element[int]{NewBaseElement(3), NewBaseElement(1)})
+	if !errors.Is(err, ErrDuplicate) {
+		t.Errorf("expected %v, got %v", ErrDuplicate, err)
+	}
+	if got, want := drain(t, q), []int{1, 2, 3}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if err := SetDedup[int, int](q, nil, DedupReject); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := q.Insert(NewBaseElement(1)); err != nil {
+			t.Errorf("dedup disabled: %v", err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"sort"
+	"time"
+)
+
+// DelayedElement is a PriorityElement that is not ready for removal from a Delayed queue before a
+// point in time.
+type DelayedElement[T any] struct {
+	PriorityElement[T]
+	readyAt time.Time
+}
+
+// ReadyAt returns the point in time from which on e can be removed from a Delayed queue.
+func (e DelayedElement[T]) ReadyAt() time.Time {
+	return e.readyAt
+}
+
+// delayed is implemented by elements that are ready at a point in time, like DelayedElement.
+type delayed interface {
+	ReadyAt() time.Time
+}
+
+// NewDelayedElement builds a new Element with the passed content that is ready for removal from a
+// Delayed queue at readyAt. Its priority is 0.
+func NewDelayedElement[T any](c T, readyAt time.Time) *DelayedElement[T] {
+	return &DelayedElement[T]{
+		PriorityElement: *NewPriorityElement(c, 0),
+		readyAt:         readyAt,
+	}
+}
+
+// readyAtOf returns the point in time at which elem is ready or the zero time if elem is always
+// ready.
+func readyAtOf[T any](elem Element[T]) time.Time {
+	if d, ok := elem.(delayed); ok {
+		return d.ReadyAt()
+	}
+	return time.Time{}
+}
+
+// headReady reports whether the head of q can be removed at now. Only the heads of Delayed queues
+// can be not ready. q must not be empty.
+// Does not lock q.
+func (q *Queue[T]) headReady(now time.Time) bool {
+	if q.order != Delayed {
+		return true
+	}
+	return !readyAtOf(q.queueSlice[q.numElements-1]).After(now)
+}
+
+// readyHeads returns how many of the first m elements in removal order are ready. It takes
+// O(log m) for Delayed queues and returns m for all others.
+// Does not lock q.
+func (q *Queue[T]) readyHeads(m int) int {
+	if q.order != Delayed {
+		return m
+	}
+	now := q.now()
+	// the heads are at the end of the slice, sorted by their ready times.
+	return sort.Search(m, func(i int) bool {
+		return readyAtOf(q.queueSlice[q.numElements-1-i]).After(now)
+	})
+}
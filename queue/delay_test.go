@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestDelayedQueue(t *testing.T) {
+	t.Parallel()
+	clock := newFakeClock()
+	q, err := NewQueue[string](Delayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.SetClock(clock)
+	start := clock.Now()
+	for _, e := range []struct {
+		content string
+		delay   time.Duration
+	}{{"c", 3 * time.Second}, {"a", time.Second}, {"b", 2 * time.Second}, {"b2", 2 * time.Second}} {
+		if err := q.Insert(NewDelayedElement(e.content, start.Add(e.delay))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err := q.Remove(); !errors.Is(err, ErrNotReady) {
+		t.Errorf("expected %v, got %v", ErrNotReady, err)
+	}
+	// elements without a ready time are ready immediately.
+	if err := q.Insert(NewBaseElement("now")); err != nil {
+		t.Fatal(err)
+	}
+	if c, _, err := q.Remove(); err != nil || c != "now" {
+		t.Errorf("expected now, got %s, %v", c, err)
+	}
+
+	clock.Advance(2 * time.Second)
+	got, err := q.RemoveBatch(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "b2"}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if q.Len() != 1 {
+		t.Errorf("expected the unready element to stay, got length %d", q.Len())
+	}
+}
+
+func TestDelayedBlockingRemove(t *testing.T) {
+	t.Parallel()
+	clock := newFakeClock()
+	q, err := NewQueue[int](Delayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.SetClock(clock)
+	if err := q.Insert(NewDelayedElement(1, clock.Now().Add(time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan int, 1)
+	go func() {
+		c, _, err := q.BlockingRemove(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		done <- c
+	}()
+
+	clock.BlockUntil(1)
+	// an earlier element wakes the remover, which then waits for the new head.
+	if err := q.Insert(NewDelayedElement(2, clock.Now().Add(time.Second))); err != nil {
+		t.Fatal(err)
+	}
+	clock.BlockUntil(2)
+	select {
+	case c := <-done:
+		t.Fatalf("removed %d before it was ready", c)
+	default:
+	}
+	clock.Advance(time.Second)
+	if c := <-done; c != 2 {
+		t.Errorf("expected 2, got %d", c)
+	}
+}
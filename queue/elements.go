@@ -22,9 +22,41 @@ func (e *PriorityElement[T]) SetContent(content T) {
 	e.content = &content
 }
 
+// SubPriorityElement is a PriorityElement with a secondary priority, which orders it among the
+// elements with the same priority in PriorityHigh and PriorityLow queues, before the tie-breaker
+// and the insertion age. Elements without sub-priority count as sub-priority 0.
+type SubPriorityElement[T any] struct {
+	PriorityElement[T]
+	subPriority float64
+}
+
+func (e SubPriorityElement[T]) SubPriority() float64 {
+	return e.subPriority
+}
+
+// SetSubPriority changes the sub-priority of e. Like with SetPriority, the queue is not reordered,
+// so this must only be done before e is inserted or followed by RebuildInvariant.
+func (e *SubPriorityElement[T]) SetSubPriority(subPriority float64) {
+	e.subPriority = subPriority
+}
+
+// subPrioritized is implemented by elements with a sub-priority, like SubPriorityElement.
+type subPrioritized interface {
+	SubPriority() float64
+}
+
+// subPriorityOf returns the sub-priority of elem or 0 if it has none.
+func subPriorityOf[T any](elem Element[T]) float64 {
+	if s, ok := elem.(subPrioritized); ok {
+		return s.SubPriority()
+	}
+	return 0
+}
+
 // BaseElement encapsulates all information that is needed for the storage in the queue.
 type BaseElement[T any] struct {
 	content *T
+	seq     uint64
 }
 
 func (e BaseElement[T]) Priority() float64 {
@@ -41,3 +73,18 @@ func (e BaseElement[T]) Content() T {
 func (e *BaseElement[T]) SetContent(content T) {
 	e.content = &content
 }
+
+func (e BaseElement[T]) sequence() uint64 {
+	return e.seq
+}
+
+func (e *BaseElement[T]) setSequence(seq uint64) {
+	e.seq = seq
+}
+
+// sequenced is implemented by elements that can store the insertion sequence number the queue
+// assigns to them on insertion.
+type sequenced interface {
+	sequence() uint64
+	setSequence(uint64)
+}
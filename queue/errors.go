@@ -1,6 +1,8 @@
 package queue
 
 import (
+	"fmt"
+
 	"github.com/pkg/errors"
 )
 
@@ -18,4 +20,88 @@ var (
 
 	// ErrInvalidQueueLimit is returned when a limit < 0 for the queue is encountered
 	ErrInvalidQueueLimit = errors.New("provided limit for queue is invalid")
+
+	// ErrInvalidOverflowPolicy is returned when a nonexistent overflow policy is encountered.
+	ErrInvalidOverflowPolicy = errors.New("provided overflow policy is invalid")
+
+	// ErrQueueFull is returned when an element is inserted into a FifoLimited queue at its limit
+	// with the RejectWithError policy.
+	ErrQueueFull = errors.New("queue is full")
+
+	// ErrElementNotFound is returned when an element is referred to that is not in the queue.
+	ErrElementNotFound = errors.New("element is not in the queue")
+
+	// ErrForeignSnapshot is returned when a queue is restored from a snapshot of another queue.
+	ErrForeignSnapshot = errors.New("snapshot was taken of another queue")
+
+	// ErrSameQueue is returned when a queue is combined with itself where that is not possible.
+	ErrSameQueue = errors.New("queue can't be combined with itself")
+
+	// ErrNotReady is returned when an element is removed from a Delayed queue whose head is not
+	// ready yet.
+	ErrNotReady = errors.New("no element is ready yet")
+
+	// ErrDuplicate is returned when an element is inserted into a queue with deduplication that
+	// already holds an element with the same key.
+	ErrDuplicate = errors.New("queue already holds an element with the same key")
+
+	// ErrInvalidDedupMode is returned when a nonexistent deduplication mode is encountered.
+	ErrInvalidDedupMode = errors.New("provided deduplication mode is invalid")
+
+	// ErrInvalidShrinkPolicy is returned when a nil ShrinkPolicy is encountered.
+	ErrInvalidShrinkPolicy = errors.New("provided shrink policy is invalid")
+
+	// ErrInvalidPriority is returned when a priority outside of the levels of a BucketQueue is
+	// encountered.
+	ErrInvalidPriority = errors.New("provided priority is out of range")
+
+	// ErrInvalidWeight is returned when a weight < 1 is encountered, or a negative or non-finite
+	// weight where fractional weights are allowed.
+	ErrInvalidWeight = errors.New("provided weight is invalid")
+
+	// ErrInvalidBuckets is returned when histogram bucket bounds are not strictly ascending.
+	ErrInvalidBuckets = errors.New("provided bucket bounds are not ascending")
+
+	// ErrInvalidTopic is returned when a malformed topic or topic pattern is encountered.
+	ErrInvalidTopic = errors.New("provided topic is invalid")
+
+	// ErrInvalidWindow is returned when a window size < 1 is encountered.
+	ErrInvalidWindow = errors.New("provided window size is invalid")
+
+	// ErrTrailingInput is returned when a stream ends with bytes that do not form a complete value.
+	ErrTrailingInput = errors.New("stream ends with an incomplete value")
+
+	// ErrCorruptData is returned when encoded queue data is malformed.
+	ErrCorruptData = errors.New("encoded queue data is corrupt")
+
+	// ErrElementTypeMismatch is returned when an element is requested as a concrete element type
+	// that it does not have.
+	ErrElementTypeMismatch = errors.New("element is not of the requested type")
+
+	// ErrUnsupportedElement is returned when an element is inserted into a queue that can't hold
+	// elements of its type.
+	ErrUnsupportedElement = errors.New("element type is not supported by the queue")
+
+	// ErrQueueClosed is returned when an element is inserted into a closed queue or when an element
+	// is removed from a closed queue that has been drained.
+	ErrQueueClosed = errors.New("queue is closed")
 )
+
+// IndexError is the error that is returned by index based operations when the provided index is
+// not within the addressable space of the queue. It records the index and the length of the queue
+// at the time of the operation. errors.Is reports it as ErrIndexOutOfBounds.
+type IndexError struct {
+	// Index is the index that was provided to the operation.
+	Index int
+
+	// Len is the number of elements that could be addressed.
+	Len int
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("%v: index %d, length %d", ErrIndexOutOfBounds, e.Index, e.Len)
+}
+
+func (e *IndexError) Unwrap() error {
+	return ErrIndexOutOfBounds
+}
@@ -1,5 +1,5 @@
 module github.com/beeemT/Datastructures-and-Algorithms/queue
 
-go 1.21
+go 1.23
 
 require github.com/pkg/errors v0.9.1
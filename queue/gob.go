@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/pkg/errors"
+)
+
+// gobQueue is the gob form of a Queue.
+type gobQueue[T any] struct {
+	Queuetype Queuetype
+	Limit     int
+	// Elements are in insertion order.
+	Elements []gobElement[T]
+}
+
+// gobElement is the gob form of an element. Base marks BaseElements and Sub elements with a
+// sub-priority, which are decoded as SubPriorityElements. All other elements are decoded as
+// PriorityElements.
+type gobElement[T any] struct {
+	Base        bool
+	Sub         bool
+	Priority    float64
+	SubPriority float64
+	Content     T
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It encodes the Queuetype, limit and elements
+// with their priorities and sub-priorities with encoding/gob, so the contents must be gob-encodable. The elements are
+// written in insertion order, so that UnmarshalBinary rebuilds the same removal order.
+// Comparators and tie-breakers are not encoded.
+// Since Queue implements encoding.BinaryMarshaler, a *Queue can be passed to a gob.Encoder directly.
+// Use SaveToFile for a checksummed encoding with a custom ContentCodec.
+// Locks q.
+func (q *Queue[T]) MarshalBinary() ([]byte, error) {
+	q.lock.RLock()
+	enc := gobQueue[T]{
+		Queuetype: q.order,
+		Limit:     q.maxnumElements,
+		Elements:  make([]gobElement[T], q.numElements),
+	}
+	for i, elem := range q.insertionOrder() {
+		_, base := elem.(*BaseElement[T])
+		_, sub := elem.(subPrioritized)
+		enc.Elements[i] = gobElement[T]{
+			Base:        base,
+			Sub:         sub,
+			Priority:    elem.Priority(),
+			SubPriority: subPriorityOf(elem),
+			Content:     elem.Content(),
+		}
+	}
+	q.lock.RUnlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(enc); err != nil {
+		return nil, errors.Wrap(err, "encoding queue")
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the Queuetype, limit and
+// contents of q with those encoded by MarshalBinary like Replace. Comparator queues can only be
+// restored into a queue built with NewQueueFunc or NewQueueWithComparator, since the comparator is
+// not encoded. The tie-breaker and overflow policy of q are kept.
+// Returns an error of type ErrCorruptData if the data is malformed.
+// Locks q.
+func (q *Queue[T]) UnmarshalBinary(data []byte) error {
+	var dec gobQueue[T]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dec); err != nil {
+		return errors.Wrapf(ErrCorruptData, "decoding queue: %v", err)
+	}
+
+	elems := make([]Element[T], len(dec.Elements))
+	for i, e := range dec.Elements {
+		switch {
+		case e.Base:
+			elems[i] = NewBaseElement(e.Content)
+		case e.Sub:
+			elems[i] = NewPriorityElement2(e.Content, e.Priority, e.SubPriority)
+		default:
+			elems[i] = NewPriorityElement(e.Content, e.Priority)
+		}
+	}
+	return q.restore(dec.Queuetype, dec.Limit, elems)
+}
+
+// RegisterGob registers *Queue[T] with encoding/gob, so that queues can be sent as values of
+// interface types, for example as any. Contents of interface types need their concrete types
+// registered with gob.Register as well.
+func RegisterGob[T any]() {
+	gob.Register(&Queue[T]{})
+}
@@ -0,0 +1,125 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// job is a gob-encodable content.
+type job struct {
+	Deadline int
+	Name     string
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[job](PriorityLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = q.SetLimit(3)
+	for i, name := range []string{"a", "b", "c", "d"} {
+		if err := q.Insert(NewPriorityElement(job{Deadline: i, Name: name}, float64(i%2))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Insert(NewBaseElement(job{Name: "e"})); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := q.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, _ := NewQueue[job](Fifo)
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	want := q.Snapshot()
+	got := restored.Snapshot()
+	if got.Len() != want.Len() {
+		t.Fatalf("expected length %d, got %d", want.Len(), got.Len())
+	}
+	for i := 0; i < want.Len(); i++ {
+		wp, wc, _ := want.At(i)
+		gp, gc, _ := got.At(i)
+		if wp != gp || wc != gc {
+			t.Errorf("expected (%v, %v) at %d, got (%v, %v)", wp, wc, i, gp, gc)
+		}
+	}
+	bases := 0
+	for _, elem := range restored.queueSlice {
+		if _, ok := elem.(*BaseElement[job]); ok {
+			bases++
+		}
+	}
+	if bases != 1 {
+		t.Errorf("expected 1 BaseElement, got %d", bases)
+	}
+}
+
+func TestBinarySubPriority(t *testing.T) {
+	t.Parallel()
+	q, _ := NewQueue[job](PriorityLow)
+	for _, elem := range []Element[job]{
+		NewPriorityElement2(job{Name: "a"}, 1, 3),
+		NewPriorityElement2(job{Name: "b"}, 1, 1),
+		NewPriorityElement2(job{Name: "c"}, 1, 2),
+		NewPriorityElement(job{Name: "d"}, 0),
+	} {
+		if err := q.Insert(elem); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := q.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, _ := NewQueue[job](Fifo)
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, j := range drain(t, restored) {
+		got = append(got, j.Name)
+	}
+	if want := []string{"d", "b", "c", "a"}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBinaryGob(t *testing.T) {
+	t.Parallel()
+	RegisterGob[int]()
+	q := fifoOf(t, 1, 2, 3)
+
+	var buf bytes.Buffer
+	var sent any = q
+	if err := gob.NewEncoder(&buf).Encode(&sent); err != nil {
+		t.Fatal(err)
+	}
+	var received any
+	if err := gob.NewDecoder(&buf).Decode(&received); err != nil {
+		t.Fatal(err)
+	}
+	restored, ok := received.(*Queue[int])
+	if !ok {
+		t.Fatalf("expected *Queue[int], got %T", received)
+	}
+	if got, want := drain(t, restored), []int{1, 2, 3}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBinaryCorrupt(t *testing.T) {
+	t.Parallel()
+	q, _ := NewQueue[int](Fifo)
+	if err := q.UnmarshalBinary([]byte("not gob")); !errors.Is(err, ErrCorruptData) {
+		t.Errorf("expected %v, got %v", ErrCorruptData, err)
+	}
+}
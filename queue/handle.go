@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ElementHandle refers to an element that was inserted with InsertHandle, so that the element can
+// be updated or removed without searching the queue for its contents, as needed for decrease-key
+// operations like in Dijkstra's algorithm.
+// A handle becomes invalid once its element leaves the queue.
+type ElementHandle[T any] struct {
+	q    *Queue[T]
+	elem Element[T]
+}
+
+// InsertHandle inserts elem like Insert and returns a handle to it.
+// The priority of elem must only be changed through the handle or the queue afterwards.
+func (q *Queue[T]) InsertHandle(elem Element[T]) (*ElementHandle[T], error) {
+	if err := q.Insert(elem); err != nil {
+		return nil, err
+	}
+	return &ElementHandle[T]{q: q, elem: elem}, nil
+}
+
+// Content returns the content of the element of h.
+func (h *ElementHandle[T]) Content() T {
+	return h.elem.Content()
+}
+
+// Priority returns the priority of the element of h.
+func (h *ElementHandle[T]) Priority() float64 {
+	h.q.lock.RLock()
+	defer h.q.lock.RUnlock()
+
+	return h.elem.Priority()
+}
+
+// SetPriority changes the priority of the element of h and moves it to its new position. The
+// element keeps its insertion age, so among elements with the same priority it is removed in the
+// same order as before.
+// For PriorityHigh, PriorityLow and Comparator queues the element is found in O(log n), otherwise
+// in O(n). Moving it costs the same as an insertion.
+// Returns ErrElementNotFound if the element is no longer in the queue.
+func (h *ElementHandle[T]) SetPriority(priority float64) error {
+	q := h.q
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	i := q.indexOf(h.elem)
+	if i < 0 {
+		return ErrElementNotFound
+	}
+	if !q.sortedByRemoval() {
+		// the position doesn't depend on the priority.
+		h.elem.SetPriority(priority)
+		return nil
+	}
+
+	if _, err := q.deleteWithoutMemoryManagement(i); err != nil {
+		return errors.Wrap(err, "removing element for reordering")
+	}
+	h.elem.SetPriority(priority)
+	capBefore := q.backingCap()
+	q.insertSorted(h.elem)
+	q.numElements++
+	q.countGrow(capBefore)
+	return nil
+}
+
+// RemoveHandle removes the element of h from q. It is found like in ElementHandle.SetPriority.
+// Returns ErrElementNotFound if the element is not in q.
+func (q *Queue[T]) RemoveHandle(h *ElementHandle[T]) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if h.q != q {
+		return ErrElementNotFound
+	}
+	i := q.indexOf(h.elem)
+	if i < 0 {
+		return ErrElementNotFound
+	}
+	_, err := q.remove(i)
+	return err
+}
+
+// sortedByRemoval reports whether queueSlice is sorted by removalCmp, so that elements can be
+// found by binary search. The other Queuetypes are only ordered by insertion age.
+// Does not lock q.
+func (q *Queue[T]) sortedByRemoval() bool {
+	return q.order == PriorityHigh || q.order == PriorityLow || q.order == Comparator || q.order == Delayed
+}
+
+// indexOf returns the index of elem in queueSlice or -1 if elem is not in the queue.
+// Does not lock q.
+func (q *Queue[T]) indexOf(elem Element[T]) int {
+	if !q.sortedByRemoval() {
+		for i := q.numElements - 1; i >= 0; i-- {
+			if q.queueSlice[i] == elem {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// the first element that is not removed after elem, followed by the elements removalCmp can't
+	// tell apart from it.
+	i := sort.Search(q.numElements, func(i int) bool {
+		return q.removalCmp(elem, q.queueSlice[i]) >= 0
+	})
+	for ; i < q.numElements && q.removalCmp(elem, q.queueSlice[i]) == 0; i++ {
+		if q.queueSlice[i] == elem {
+			return i
+		}
+	}
+	return -1
+}
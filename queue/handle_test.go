@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestElementHandleSetPriority(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[string](PriorityLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handles := map[string]*ElementHandle[string]{}
+	for _, c := range []struct {
+		content  string
+		priority float64
+	}{{"a", 3}, {"b", 2}, {"c", 2}, {"d", 5}, {"e", 1}} {
+		h, err := q.InsertHandle(NewPriorityElement(c.content, c.priority))
+		if err != nil {
+			t.Fatal(err)
+		}
+		handles[c.content] = h
+	}
+
+	// "d" decreases to the priority of "b" and "c" but stays the youngest of them.
+	if err := handles["d"].SetPriority(2); err != nil {
+		t.Fatal(err)
+	}
+	// "e" increases behind "a".
+	if err := handles["e"].SetPriority(3); err != nil {
+		t.Fatal(err)
+	}
+	if p := handles["d"].Priority(); p != 2 {
+		t.Errorf("expected priority 2, got %v", p)
+	}
+
+	if got, want := drain(t, q), []string{"b", "c", "d", "a", "e"}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if err := handles["a"].SetPriority(0); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("expected %v, got %v", ErrElementNotFound, err)
+	}
+}
+
+func TestRemoveHandle(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, PriorityHigh} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var handles []*ElementHandle[int]
+		for i := 0; i < 5; i++ {
+			// equal priorities, so the handles must be told apart by their age.
+			h, err := q.InsertHandle(NewPriorityElement(i, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			handles = append(handles, h)
+		}
+
+		for _, i := range []int{3, 0} {
+			if err := q.RemoveHandle(handles[i]); err != nil {
+				t.Fatalf("queuetype %v: %v", tp, err)
+			}
+		}
+		if err := q.RemoveHandle(handles[3]); !errors.Is(err, ErrElementNotFound) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrElementNotFound, err)
+		}
+		other, _ := NewQueue[int](tp)
+		if err := other.RemoveHandle(handles[1]); !errors.Is(err, ErrElementNotFound) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrElementNotFound, err)
+		}
+
+		if got, want := drain(t, q), []int{1, 2, 4}; !equalContents(got, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, got)
+		}
+	}
+}
+
+func TestElementHandleAfterUpdatePriority(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		q, err := NewQueue[string](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hA, err := q.InsertHandle(NewPriorityElement("a", 2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hD, err := q.InsertHandle(NewPriorityElement("d", 2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range []string{"b", "c"} {
+			if err := q.Insert(NewPriorityElement(c, 1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// "a" and "d" join "b" and "c" and are removed after them.
+		if n := q.UpdatePriority(2, 1, false); n != 2 {
+			t.Fatalf("queuetype %v: expected 2 updates, got %d", tp, n)
+		}
+		if err := hD.SetPriority(1); err != nil {
+			t.Errorf("queuetype %v: %v", tp, err)
+		}
+		if err := q.RemoveHandle(hA); err != nil {
+			t.Errorf("queuetype %v: %v", tp, err)
+		}
+		if got, want := drain(t, q), []string{"b", "c", "d"}; !equalContents(got, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package queue
+
+// OnInsert registers hook to be called with every element that enters q, after it was placed. An
+// element that a full FifoLimited queue drops right away does not enter q. Elements that only
+// move within q, e.g. through UpdatePriority or Reschedule, are not reported again.
+// The hooks run in the order of their registration while q is locked, so they must not call
+// methods of q.
+// Locks q.
+func (q *Queue[T]) OnInsert(hook func(Element[T])) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.onInsert = append(q.onInsert, hook)
+}
+
+// OnRemove registers hook to be called with every element that leaves q, whether it was removed,
+// evicted, expired, drained, replaced or merged into another queue.
+// Like OnInsert hooks the hooks run while q is locked and must not call methods of q.
+// Locks q.
+func (q *Queue[T]) OnRemove(hook func(Element[T])) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.onRemove = append(q.onRemove, hook)
+}
+
+// entered records that elem entered q.
+// Does not lock q.
+func (q *Queue[T]) entered(elem Element[T]) {
+	q.track(elem)
+	q.hookInserted(elem)
+}
+
+// left records that elem left q.
+// Does not lock q.
+func (q *Queue[T]) left(elem Element[T]) {
+	q.untrack(elem)
+	q.hookRemoved(elem)
+}
+
+// hookInserted calls the insertion hooks of q and records the insertion time of elem.
+// Does not lock q.
+func (q *Queue[T]) hookInserted(elem Element[T]) {
+	if q.insertedAt != nil {
+		q.insertedAt[elem] = q.now()
+	}
+	for _, hook := range q.onInsert {
+		hook(elem)
+	}
+}
+
+// hookRemoved calls the removal hooks of q and records the time elem spent in q.
+// Does not lock q.
+func (q *Queue[T]) hookRemoved(elem Element[T]) {
+	if at, ok := q.insertedAt[elem]; ok {
+		delete(q.insertedAt, elem)
+		q.counters.dwellTotal.Add(int64(q.now().Sub(at)))
+		q.counters.dwellCount.Add(1)
+	}
+	for _, hook := range q.onRemove {
+		hook(elem)
+	}
+}
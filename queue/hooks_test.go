@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestInsertRemoveHooks(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](FifoLimited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetLimit(2); err != nil {
+		t.Fatal(err)
+	}
+	var inserted, removed []int
+	q.OnInsert(func(e Element[int]) { inserted = append(inserted, e.Content()) })
+	q.OnRemove(func(e Element[int]) { removed = append(removed, e.Content()) })
+
+	for i := 0; i < 3; i++ {
+		if err := q.Insert(NewPriorityElement(i, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// moving elements within the queue is not reported.
+	q.UpdatePriority(1, 2, false)
+	if _, _, err := q.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	q.Replace([]Element[int]{NewBaseElement(7)})
+
+	if want := []int{0, 1, 2, 7}; !equalContents(inserted, want) {
+		t.Errorf("expected inserted %v, got %v", want, inserted)
+	}
+	if want := []int{0, 1, 2}; !equalContents(removed, want) {
+		t.Errorf("expected removed %v, got %v", want, removed)
+	}
+}
+
+func TestInsertHooksDropNewest(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](FifoLimited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetLimitWithPolicy(1, DropNewest); err != nil {
+		t.Fatal(err)
+	}
+	var inserted int
+	q.OnInsert(func(Element[int]) { inserted++ })
+
+	for i := 0; i < 3; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if inserted != 1 {
+		t.Errorf("expected 1 insertion, got %d", inserted)
+	}
+}
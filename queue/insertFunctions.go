@@ -1,94 +1,175 @@
 package queue
 
-import "github.com/pkg/errors"
+import (
+	"slices"
+	"sort"
 
+	"github.com/pkg/errors"
+)
+
+// minFifoHeadroom is the least amount of free slots reserveFront places in front of the elements.
+const minFifoHeadroom = 4
+
+// insertFifo prepends elem in O(1) amortized. queueSlice is a window at the end of fifoBuf, so the
+// free slots in front of it are used until they run out and reserveFront makes new room.
 func (q *Queue[T]) insertFifo(elem Element[T]) {
-	q.queueSlice = append([]Element[T]{elem}, q.queueSlice...)
+	if !q.sharesFifoBuf() || cap(q.fifoBuf) == cap(q.queueSlice) {
+		q.reserveFront()
+	}
+	start := cap(q.fifoBuf) - cap(q.queueSlice) - 1
+	q.queueSlice = q.fifoBuf[start : start+len(q.queueSlice)+1]
+	q.queueSlice[0] = elem
 }
 
-func (q *Queue[T]) insertLifo(elem Element[T]) {
-	q.queueSlice = append(q.queueSlice, elem)
+// reserveFront makes room in front of queueSlice for at least as many elements as it holds, so
+// that prepending costs O(1) amortized. Free slots behind the elements are reused by moving the
+// elements to the end of fifoBuf if they suffice, otherwise fifoBuf is reallocated.
+func (q *Queue[T]) reserveFront() {
+	n := len(q.queueSlice)
+	headroom := max(n, minFifoHeadroom, q.initialCap-n)
+	if q.sharesFifoBuf() && cap(q.queueSlice)-n >= headroom {
+		start := cap(q.fifoBuf) - cap(q.queueSlice)
+		newStart := cap(q.fifoBuf) - n
+		copy(q.fifoBuf[newStart:], q.queueSlice)
+		// the vacated slots must not keep the moved elements alive.
+		clear(q.fifoBuf[start:min(start+n, newStart)])
+		q.queueSlice = q.fifoBuf[newStart:]
+		return
+	}
+
+	buf := make([]Element[T], headroom+n)
+	copy(buf[headroom:], q.queueSlice)
+	q.fifoBuf = buf
+	q.queueSlice = buf[headroom:]
 }
 
-func (q *Queue[T]) insertPriorityHigh(elem Element[T]) {
-	// If the queue is empty or the new element has a higher priority than the current item with the
-	// highest priority
-	// it can be appended to the slice.
-	if q.numElements == 0 || (q.queueSlice[q.numElements-1]).Priority() < elem.Priority() {
-		q.queueSlice = append(q.queueSlice, elem)
-		return
+// sharesFifoBuf reports whether queueSlice is a window of fifoBuf. It is not once queueSlice was
+// reallocated by anything else than insertFifo.
+func (q *Queue[T]) sharesFifoBuf() bool {
+	c := cap(q.queueSlice)
+	if len(q.fifoBuf) == 0 || c == 0 {
+		return false
 	}
+	// every window of fifoBuf reaches up to its end.
+	return &q.fifoBuf[len(q.fifoBuf)-1] == &q.queueSlice[:c][c-1]
+}
 
-	if (q.queueSlice[q.numElements-1]).Priority() == elem.Priority() {
-		q.backtrackInsertionPoint(elem)
+// backingCap returns the capacity of the array backing the queue, including the free slots in
+// front of queueSlice.
+func (q *Queue[T]) backingCap() int {
+	if q.sharesFifoBuf() {
+		return cap(q.fifoBuf)
 	}
+	return cap(q.queueSlice)
+}
 
-	// Default case. Iterate through full queue until the first suitable spot for the new element is
-	// found.
-	for i, e := range q.queueSlice {
-		if e.Priority() < elem.Priority() {
-			continue
-		}
+func (q *Queue[T]) insertLifo(elem Element[T]) {
+	q.queueSlice = append(q.queueSlice, elem)
+}
 
-		// e.prio >= elem.prio
-		q.queueSlice = append(
-			q.queueSlice[:(i-1)],
-			append([]Element[T]{elem}, q.queueSlice[(i-1):]...)...)
-		break
-	}
+// insertPriorityHigh inserts elem into the ascending priorities of queueSlice. The insertion point
+// is found by binary search in front of all elements with the same priority and sub-priority, so
+// that elem is removed after them.
+func (q *Queue[T]) insertPriorityHigh(elem Element[T]) {
+	p, s := elem.Priority(), subPriorityOf(elem)
+	i := sort.Search(q.numElements, func(i int) bool {
+		other := q.queueSlice[i].Priority()
+		return other > p || other == p && subPriorityOf(q.queueSlice[i]) >= s
+	})
+	q.insertAt(i, elem)
 }
 
+// insertPriorityLow inserts elem into the descending priorities of queueSlice. The insertion point
+// is found by binary search in front of all elements with the same priority and sub-priority, so
+// that elem is removed after them.
 func (q *Queue[T]) insertPriorityLow(elem Element[T]) {
-	// If the queue is empty or the new element has a lower priority than the current item with the
-	// lowest priority
-	// it can be appended to the slice.
-	if q.numElements == 0 || (q.queueSlice[q.numElements-1]).Priority() > elem.Priority() {
-		q.queueSlice = append(q.queueSlice, elem)
-		return
-	}
+	p, s := elem.Priority(), subPriorityOf(elem)
+	i := sort.Search(q.numElements, func(i int) bool {
+		other := q.queueSlice[i].Priority()
+		return other < p || other == p && subPriorityOf(q.queueSlice[i]) <= s
+	})
+	q.insertAt(i, elem)
+}
 
-	if (q.queueSlice[q.numElements-1]).Priority() == elem.Priority() {
-		q.backtrackInsertionPoint(elem)
-	}
+// mergeSorted merges elems into the sorted queueSlice of a Priority or Comparator queue in
+// O(n + k log k) without changing numElements. elems must be stamped and are not modified.
+func (q *Queue[T]) mergeSorted(elems []Element[T]) {
+	batch := slices.Clone(elems)
+	// the element that is removed first belongs to the end of the slice.
+	slices.SortStableFunc(batch, func(a, b Element[T]) int {
+		return q.removalCmp(b, a)
+	})
+	q.merge(batch)
+}
 
-	// Default case. Iterate through full queue until the first suitable spot for the new element is
-	// found.
-	for i, e := range q.queueSlice {
-		if e.Priority() > elem.Priority() {
-			continue
+// merge merges batch, which must be ordered like queueSlice, into queueSlice in O(n + k) without
+// changing numElements. Requires queueSlice to be sorted by removalCmp.
+func (q *Queue[T]) merge(batch []Element[T]) {
+	n := len(q.queueSlice)
+	q.queueSlice = slices.Grow(q.queueSlice, len(batch))[:n+len(batch)]
+	// merge from the end, the elements that are removed first, so that no element is overwritten
+	// before it is moved. Existing elements win ties, they are older.
+	i, j := n-1, len(batch)-1
+	for p := len(q.queueSlice) - 1; j >= 0; p-- {
+		if i >= 0 && q.removalCmp(q.queueSlice[i], batch[j]) <= 0 {
+			q.queueSlice[p] = q.queueSlice[i]
+			i--
+		} else {
+			q.queueSlice[p] = batch[j]
+			j--
 		}
-
-		// e.prio <= elem.prio
-		q.queueSlice = append(
-			q.queueSlice[:(i-1)],
-			append([]Element[T]{elem}, q.queueSlice[(i-1):]...)...)
-		break
 	}
 }
 
-// backtrackInsertionPoint finds a suitable insertion point for an item from the back of the queue.
-// queueSlice[q.numElements-1].Priority() == elem.Priority() doesn't need full iteration over queue
-// to find the spot for insertion. Iteration from slice end to first element which has non equal
-// priority than the new element is enough because of the priority invariant.
-// In the worst case this will iterate over whole queue, so attach new element to front of slice.
-func (q *Queue[T]) backtrackInsertionPoint(elem Element[T]) {
-	for i := q.numElements - 1; i > -1; i-- {
-		if q.queueSlice[i].Priority() == elem.Priority() {
-			continue
+// insertAt inserts elem at index i of queueSlice, shifting all elements from i onwards one to the
+// back.
+func (q *Queue[T]) insertAt(i int, elem Element[T]) {
+	q.queueSlice = append(q.queueSlice, nil)
+	copy(q.queueSlice[i+1:], q.queueSlice[i:])
+	q.queueSlice[i] = elem
+}
+
+// insertFifoLimited inserts elem like insertFifo and applies the overflow policy if the queue is at
+// its limit. Returns whether elem was placed in the queue.
+func (q *Queue[T]) insertFifoLimited(elem Element[T]) (bool, error) {
+	if q.maxnumElements == 0 || q.numElements < q.maxnumElements {
+		q.insertFifo(elem)
+		return true, nil
+	}
+
+	policy := q.policy
+	if q.order == LRU {
+		policy = DropOldest
+	}
+	victim := q.numElements - 1
+	switch policy {
+	case DropNewest:
+		q.counters.evictions.Add(1)
+		return false, nil
+	case RejectWithError:
+		return false, ErrQueueFull
+	case EvictLowestPriority:
+		victim = q.lowestPriorityIndex()
+		if elem.Priority() < q.queueSlice[victim].Priority() {
+			q.counters.evictions.Add(1)
+			return false, nil
 		}
-		q.queueSlice = append(q.queueSlice[i:], append([]Element[T]{elem}, q.queueSlice[:i]...)...)
-		return
 	}
-	q.queueSlice = append([]Element[T]{elem}, q.queueSlice...)
+	if _, err := q.evict(victim); err != nil {
+		return false, errors.Wrap(err, "popping element because of overflow")
+	}
+	q.insertFifo(elem)
+	return true, nil
 }
 
-func (q *Queue[T]) insertFifoLimited(elem Element[T]) error {
-	if q.numElements == q.maxnumElements && q.maxnumElements != 0 {
-		_, err := q.remove(q.numElements - 1)
-		if err != nil {
-			return errors.Wrap(err, "popping element because of overflow")
+// lowestPriorityIndex returns the index of the oldest element with the lowest priority in an
+// unsorted queue in O(n). The queue must not be empty.
+func (q *Queue[T]) lowestPriorityIndex() int {
+	lowest := q.numElements - 1
+	for i := lowest - 1; i >= 0; i-- {
+		if q.queueSlice[i].Priority() < q.queueSlice[lowest].Priority() {
+			lowest = i
 		}
 	}
-	q.insertFifo(elem)
-	return nil
+	return lowest
 }
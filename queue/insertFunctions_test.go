@@ -0,0 +1,268 @@
+package queue
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestInsertPriorityOrder(t *testing.T) {
+	t.Parallel()
+	priorities := []float64{3, 1, 4, 1, 5, 9, 2, 6, 5, 3, 5}
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range priorities {
+			if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		lastContent, lastPrio, err := q.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for q.Len() > 0 {
+			content, prio, err := q.Remove()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (tp == PriorityHigh && prio > lastPrio) || (tp == PriorityLow && prio < lastPrio) {
+				t.Errorf("queuetype %v: priority %v removed after %v", tp, prio, lastPrio)
+			}
+			if prio == lastPrio && content < lastContent {
+				t.Errorf("queuetype %v: element %d removed after younger element %d", tp, content, lastContent)
+			}
+			lastContent, lastPrio = content, prio
+		}
+	}
+}
+
+func TestInsertFifoMixed(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, FifoLimited} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		const limit = 300
+		if tp == FifoLimited {
+			_ = q.SetLimit(limit)
+		}
+
+		// model holds the contents in removal order.
+		var model []int
+		r := rand.New(rand.NewSource(42))
+		for i := 0; i < 20000; i++ {
+			// phases of growing and shrinking, so that the front is refilled from both the free slots
+			// behind the elements and from reallocations.
+			grow := (i/2000)%2 == 0
+			if r.Intn(3) > 0 == grow || len(model) == 0 {
+				if err := q.Insert(NewBaseElement(i)); err != nil {
+					t.Fatal(err)
+				}
+				model = append(model, i)
+				if tp == FifoLimited && len(model) > limit {
+					model = model[1:]
+				}
+				continue
+			}
+
+			got, _, err := q.Remove()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != model[0] {
+				t.Fatalf("queuetype %v, step %d: expected %d, got %d", tp, i, model[0], got)
+			}
+			model = model[1:]
+		}
+
+		if got := drain(t, q); !equalContents(got, model) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, model, got)
+		}
+	}
+}
+
+func TestInsertFifoGrows(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 1 << 14
+	for i := 0; i < n; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the room in front of the elements doubles, so it is only reallocated O(log n) times.
+	if grows := q.Metrics().Grows; grows > 16 {
+		t.Errorf("expected at most 16 grows for %d inserts, got %d", n, grows)
+	}
+	if q.Capacity() < n || q.Capacity() > 2*n {
+		t.Errorf("expected a capacity between %d and %d, got %d", n, 2*n, q.Capacity())
+	}
+	for i := 0; i < n; i++ {
+		got, _, err := q.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != i {
+			t.Fatalf("expected %d, got %d", i, got)
+		}
+	}
+}
+
+func TestInsertFifoReleasesRemovedSlots(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		_ = q.Insert(NewBaseElement(i))
+	}
+	for i := 0; i < 100; i++ {
+		_ = q.Insert(NewBaseElement(i))
+		_, _, _ = q.Remove()
+	}
+
+	// all slots outside of the elements are free, so removed elements can be collected.
+	for i, e := range q.fifoBuf {
+		inWindow := i >= cap(q.fifoBuf)-cap(q.queueSlice) && i < cap(q.fifoBuf)-cap(q.queueSlice)+q.numElements
+		if !inWindow && e != nil {
+			t.Fatalf("slot %d outside of the elements holds %v", i, e.Content())
+		}
+	}
+}
+
+// BenchmarkInsertFifo inserts into and removes from a Fifo queue that holds 10000 elements.
+func BenchmarkInsertFifo(b *testing.B) {
+	q, _ := NewQueue[int](Fifo)
+	for i := 0; i < 10000; i++ {
+		_ = q.Insert(NewBaseElement(i))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = q.Insert(NewBaseElement(i))
+		_, _, _ = q.Remove()
+	}
+}
+
+// BenchmarkInsertPriorityHigh inserts random priorities into and removes from a PriorityHigh queue
+// that holds 10000 elements.
+func BenchmarkInsertPriorityHigh(b *testing.B) {
+	r := rand.New(rand.NewSource(42))
+	q, _ := NewQueue[int](PriorityHigh)
+	for i := 0; i < 10000; i++ {
+		_ = q.Insert(NewPriorityElement(i, r.Float64()))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = q.Insert(NewPriorityElement(i, r.Float64()))
+		_, _, _ = q.Remove()
+	}
+}
+
+// BenchmarkInsertValue is BenchmarkInsertPriorityHigh with InsertValue.
+func BenchmarkInsertValue(b *testing.B) {
+	r := rand.New(rand.NewSource(42))
+	q, _ := NewQueue[int](PriorityHigh)
+	for i := 0; i < 10000; i++ {
+		_ = q.InsertValue(i, r.Float64())
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = q.InsertValue(i, r.Float64())
+		_, _, _ = q.Remove()
+	}
+}
+
+func TestInsertPriorityTies(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// blocks of equal priorities at the ends and in the middle of the queue.
+		for i, p := range []float64{2, 2, 0, 4, 2, 0, 4, 2} {
+			_ = q.Insert(NewPriorityElement(i, p))
+		}
+
+		want := []int{3, 6, 0, 1, 4, 7, 2, 5}
+		if tp == PriorityLow {
+			want = []int{2, 5, 0, 1, 4, 7, 3, 6}
+		}
+		if got := drain(t, q); !equalContents(got, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, got)
+		}
+	}
+}
+
+func TestInsertFifoLimitedPolicies(t *testing.T) {
+	t.Parallel()
+	// a full queue with the priorities 2, 1, 1 receives an element with priority last.
+	cases := []struct {
+		policy    OverflowPolicy
+		last      float64
+		want      []int
+		wantErr   error
+		evictions uint64
+	}{
+		{DropOldest, 5, []int{1, 2, 3}, nil, 1},
+		{DropNewest, 5, []int{0, 1, 2}, nil, 1},
+		{RejectWithError, 5, []int{0, 1, 2}, ErrQueueFull, 0},
+		{EvictLowestPriority, 5, []int{0, 2, 3}, nil, 1},
+		{EvictLowestPriority, 1, []int{0, 2, 3}, nil, 1},
+		{EvictLowestPriority, 0, []int{0, 1, 2}, nil, 1},
+	}
+
+	for _, c := range cases {
+		q, err := NewQueue[int](FifoLimited)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := q.SetLimitWithPolicy(3, c.policy); err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range []float64{2, 1, 1} {
+			if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := q.Insert(NewPriorityElement(3, c.last)); !errors.Is(err, c.wantErr) {
+			t.Errorf("policy %v, priority %v: expected %v, got %v", c.policy, c.last, c.wantErr, err)
+		}
+		if got := q.Metrics().Evictions; got != c.evictions {
+			t.Errorf("policy %v, priority %v: expected %d evictions, got %d", c.policy, c.last, c.evictions, got)
+		}
+		if got := drain(t, q); !equalContents(got, c.want) {
+			t.Errorf("policy %v, priority %v: expected %v, got %v", c.policy, c.last, c.want, got)
+		}
+	}
+}
+
+func TestSetLimitWithPolicyErrors(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](FifoLimited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetLimitWithPolicy(1, numOverflowPolicies); !errors.Is(err, ErrInvalidOverflowPolicy) {
+		t.Errorf("expected %v, got %v", ErrInvalidOverflowPolicy, err)
+	}
+	if err := q.SetLimitWithPolicy(-1, DropNewest); !errors.Is(err, ErrInvalidQueueLimit) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueLimit, err)
+	}
+}
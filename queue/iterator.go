@@ -2,19 +2,207 @@ package queue
 
 import (
 	"context"
-	"sync"
+	"hash/maphash"
+	"iter"
+	"slices"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 // Iterator returns a channel which streams all elements of the queue.
+// The elements are streamed in internal slice order, which is the reverse of the removal order.
+// Use IteratorOrdered or IteratorReverse to rely on a traversal order.
 // The amount of items cached in the channel can be determined by channelCapacity.
 // The iterator can be stopped prematurely with the returned cancel function.
-// Behaviour on concurrent calls to the queue iterator is undefined.
+// The contents are snapshotted under lock when Iterator is called, so later changes to the queue
+// are not reflected and concurrent iterators each stream their own consistent view.
 func (q *Queue[T]) Iterator(channelCapacity int) (<-chan T, context.CancelFunc) {
+	return streamContents(q.snapshotContents(), channelCapacity)
+}
+
+// IteratorOrdered returns a channel which streams all elements of the queue in removal order, the
+// front of the queue first. Apart from the order it behaves like Iterator.
+func (q *Queue[T]) IteratorOrdered(channelCapacity int) (<-chan T, context.CancelFunc) {
+	contents := q.snapshotContents()
+	slices.Reverse(contents)
+	return streamContents(contents, channelCapacity)
+}
+
+// IteratorReverse returns a channel which streams all elements of the queue in reverse removal
+// order, the element that is removed last first. Apart from the order it behaves like Iterator.
+func (q *Queue[T]) IteratorReverse(channelCapacity int) (<-chan T, context.CancelFunc) {
+	return streamContents(q.snapshotContents(), channelCapacity)
+}
+
+// All returns an iterator over the contents of all elements in removal order for range-over-func
+// loops. Unlike Iterator it needs no goroutine, so stopping the loop early leaks nothing.
+// Every iteration snapshots the queue under lock when it starts, so the loop body may modify the
+// queue without affecting the iteration.
+func (q *Queue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, e := range q.Snapshot().entries {
+			if !yield(e.content) {
+				return
+			}
+		}
+	}
+}
+
+// Elements returns an iterator over the priorities and contents of all elements in removal order
+// like All.
+func (q *Queue[T]) Elements() iter.Seq2[float64, T] {
+	return func(yield func(float64, T) bool) {
+		for _, e := range q.Snapshot().entries {
+			if !yield(e.priority, e.content) {
+				return
+			}
+		}
+	}
+}
+
+// snapshotContents returns the contents of all elements in internal slice order.
+// Locks q.
+func (q *Queue[T]) snapshotContents() []T {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	contents := make([]T, q.numElements)
+	for i, elem := range q.queueSlice {
+		contents[i] = elem.Content()
+	}
+	return contents
+}
+
+// streamContents streams contents on the returned channel until all sent or cancel is called.
+func streamContents[T any](contents []T, channelCapacity int) (<-chan T, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return sendContents(ctx, cancel, contents, channelCapacity), cancel
+}
+
+// sendContents streams contents on the returned channel until all are sent or ctx is done.
+// The channel is closed afterwards. cancel is called once all contents are sent to release ctx.
+func sendContents[T any](
+	ctx context.Context,
+	cancel context.CancelFunc,
+	contents []T,
+	channelCapacity int,
+) <-chan T {
+	ch := make(chan T, channelCapacity)
+	go func() {
+		defer func() {
+			if !errors.Is(ctx.Err(), context.Canceled) {
+				cancel()
+			}
+			close(ch)
+		}()
+
+		for _, c := range contents {
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- c:
+			}
+		}
+	}()
+
+	return ch
+}
+
+// Broadcast returns n channels which each stream all elements of the queue in the same order as
+// Iterator. The amount of items cached in each channel can be determined by channelCapacity.
+// Every channel is fed by its own goroutine, so a slow consumer does not hold back the others.
+// The contents are snapshotted under lock when Broadcast is called.
+// The returned cancel function stops the streaming and closes all channels. It should be called
+// once the channels are no longer needed.
+func (q *Queue[T]) Broadcast(channelCapacity int, n int) ([]<-chan T, context.CancelFunc) {
+	contents := q.snapshotContents()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	chs := make([]<-chan T, n)
+	for i := range chs {
+		// every channel gets its own child context, so that a finished channel does not cancel
+		// the others.
+		chCtx, chCancel := context.WithCancel(ctx)
+		chs[i] = sendContents(chCtx, chCancel, contents, channelCapacity)
+	}
+
+	return chs, cancel
+}
+
+// FanOut returns n channels which together stream all elements of the queue in removal order,
+// the i-th element on the channel i mod n, so that a pool of n workers can consume the queue
+// without a dispatcher. The amount of items cached in each channel can be determined by
+// channelCapacity. Every channel is fed by its own goroutine like in Broadcast.
+// The contents are snapshotted under lock when FanOut is called. The returned cancel function
+// stops the streaming and closes all channels. No channels are returned for n < 1.
+func (q *Queue[T]) FanOut(n int, channelCapacity int) ([]<-chan T, context.CancelFunc) {
+	i := 0
+	return q.fanOut(n, channelCapacity, func(T) int {
+		i++
+		return (i - 1) % n
+	})
+}
+
+// FanOutByKey works like FanOut, but streams every element on the channel selected by the hash of
+// key, so that the elements with the same key are consumed by the same worker in removal order.
+func (q *Queue[T]) FanOutByKey(
+	n int,
+	channelCapacity int,
+	key func(T) string,
+) ([]<-chan T, context.CancelFunc) {
+	seed := maphash.MakeSeed()
+	return q.fanOut(n, channelCapacity, func(c T) int {
+		return int(maphash.String(seed, key(c)) % uint64(n))
+	})
+}
+
+// fanOut streams the contents of q in removal order on n channels, each on the channel chosen by
+// pick.
+func (q *Queue[T]) fanOut(
+	n int,
+	channelCapacity int,
+	pick func(T) int,
+) ([]<-chan T, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if n < 1 {
+		return nil, cancel
+	}
+	contents := q.snapshotContents()
+	slices.Reverse(contents)
+	parts := make([][]T, n)
+	for _, c := range contents {
+		i := pick(c)
+		parts[i] = append(parts[i], c)
+	}
+
+	chs := make([]<-chan T, n)
+	for i := range chs {
+		chCtx, chCancel := context.WithCancel(ctx)
+		chs[i] = sendContents(chCtx, chCancel, parts[i], channelCapacity)
+	}
+
+	return chs, cancel
+}
+
+// MergeIterator returns a channel which streams the elements of a and b in their merged removal
+// order, without building an intermediate queue. The elements are merged according to the order
+// of a, the Queuetype of b should match it. Elements that a can't tell apart by its main ordering
+// property are streamed from a first, so for Queuetypes without one, e.g. Fifo, all elements of a
+// come before the elements of b.
+// The contents of a and b are snapshotted under their locks when MergeIterator is called, one
+// after the other. The amount of items cached in the channel can be determined by
+// channelCapacity. The returned cancel function stops the streaming and closes the channel.
+func MergeIterator[T any](a, b *Queue[T], channelCapacity int) (<-chan T, context.CancelFunc) {
+	a.lock.RLock()
+	entriesA := a.snapshotEntries()
+	order := a.ordering()
+	a.lock.RUnlock()
+	entriesB := b.Snapshot().entries
+
 	ch := make(chan T, channelCapacity)
 	ctx, cancel := context.WithCancel(context.Background())
-	go func(ctx context.Context, cancel context.CancelFunc) {
+	go func() {
 		defer func() {
 			if !errors.Is(ctx.Err(), context.Canceled) {
 				cancel()
@@ -22,15 +210,72 @@ func (q *Queue[T]) Iterator(channelCapacity int) (<-chan T, context.CancelFunc)
 			close(ch)
 		}()
 
-		for _, elem := range q.queueSlice {
+		for i, j := 0, 0; i < len(entriesA) || j < len(entriesB); {
+			var next T
+			if j == len(entriesB) || (i < len(entriesA) && order.mainCmp(entriesA[i], entriesB[j]) <= 0) {
+				next = entriesA[i].content
+				i++
+			} else {
+				next = entriesB[j].content
+				j++
+			}
+
 			select {
 			case <-ctx.Done():
 				return
-			default:
-				ch <- elem.Content()
+			case ch <- next:
 			}
 		}
-	}(ctx, cancel)
+	}()
+
+	return ch, cancel
+}
+
+// ThrottledIterator returns a channel which streams all elements of the queue like Iterator, but
+// waits interval between two sends, e.g. to replay queued events at a controlled rate. The first
+// element is sent right away.
+// The contents are snapshotted under lock when ThrottledIterator is called.
+// The returned cancel function stops the streaming, also during a pending interval, and closes the
+// channel.
+func (q *Queue[T]) ThrottledIterator(
+	channelCapacity int,
+	interval time.Duration,
+) (<-chan T, context.CancelFunc) {
+	return q.ThrottledIteratorWithClock(channelCapacity, interval, realClock{})
+}
+
+// ThrottledIteratorWithClock works like ThrottledIterator, but measures the intervals with clock.
+func (q *Queue[T]) ThrottledIteratorWithClock(
+	channelCapacity int,
+	interval time.Duration,
+	clock Clock,
+) (<-chan T, context.CancelFunc) {
+	contents := q.snapshotContents()
+	ch := make(chan T, channelCapacity)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer func() {
+			if !errors.Is(ctx.Err(), context.Canceled) {
+				cancel()
+			}
+			close(ch)
+		}()
+
+		for i, c := range contents {
+			if i > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-clock.After(interval):
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- c:
+			}
+		}
+	}()
 
 	return ch, cancel
 }
@@ -52,6 +297,7 @@ func (q *Queue[T]) MapInPlaceUnsecure(f func(T) (T, error)) error {
 		}
 		elem.SetContent(newContent)
 	}
+	q.reindex()
 
 	return nil
 }
@@ -84,6 +330,46 @@ func (q *Queue[T]) FilterInPlaceUnsecure(f func(T) (bool, error)) error {
 	return nil
 }
 
+// DrainFilter removes all elements for which pred returns true and returns their contents in
+// removal order. The remaining elements keep their order. Takes a single O(n) pass.
+// Locks q.
+func (q *Queue[T]) DrainFilter(pred func(T) bool) []T {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	removed := q.removeWhere(func(elem Element[T]) bool {
+		return pred(elem.Content())
+	})
+	q.counters.removes.Add(uint64(len(removed)))
+
+	ret := make([]T, len(removed))
+	for i, elem := range removed {
+		ret[i] = elem.Content()
+	}
+	return ret
+}
+
+// Drain atomically removes all elements and returns their contents in removal order, leaving the
+// queue empty. Returns an empty slice if the queue is empty.
+// Locks q.
+func (q *Queue[T]) Drain() []T {
+	return q.DrainTo(nil)
+}
+
+// DrainTo atomically removes all elements like Drain and appends their contents to dst in removal
+// order. Returns the extended slice, so that a buffer can be reused across calls.
+// Locks q.
+func (q *Queue[T]) DrainTo(dst []T) []T {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	dst = slices.Grow(dst, q.numElements)
+	for _, elem := range q.dropExpired(q.removeHeads(q.numElements)) {
+		dst = append(dst, elem.Content())
+	}
+	return dst
+}
+
 // Fold executes a right fold fold function on all elements in the queue.
 // Locks the queue.
 func Fold[Aggregate, T any](
@@ -133,17 +419,23 @@ func Map[Told, Tnew any](
 // queue.
 // The mapping function is responsible for the element projection and can determine whether the item
 // should be included in the new queue.
+// Comparator queues can't be mapped, since their comparator doesn't apply to the new content type.
 // Does not lock q.
 func MapUnsecure[Told, Tnew any](
 	q *Queue[Told],
 	f func(Element[Told]) (Element[Tnew], bool, error),
 ) (*Queue[Tnew], error) {
+	if q.order == Comparator {
+		return nil, ErrInvalidQueueType
+	}
+
 	newQueue := &Queue[Tnew]{
 		order:          q.order,
 		queueSlice:     make([]Element[Tnew], q.numElements),
 		numElements:    q.numElements,
 		maxnumElements: q.maxnumElements,
-		lock:           sync.Mutex{},
+		policy:         q.policy,
+		lock:           queueLock{disabled: q.lock.disabled},
 	}
 
 	for i, elem := range q.queueSlice {
@@ -156,3 +448,178 @@ func MapUnsecure[Told, Tnew any](
 
 	return newQueue, nil
 }
+
+// Filter returns a new queue with the configuration of q holding the elements for which pred
+// returns true, together with the numbers of kept and dropped elements. The kept elements keep
+// their order and are shared with q like in Clone. q is not changed.
+// Locks q.
+func (q *Queue[T]) Filter(pred func(T) bool) (*Queue[T], int, int) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	kept := make([]Element[T], 0, q.numElements)
+	for _, elem := range q.queueSlice {
+		if pred(elem.Content()) {
+			kept = append(kept, elem)
+		}
+	}
+	return q.cloneWith(kept), len(kept), q.numElements - len(kept)
+}
+
+// FilterMap projects the contents of q with f in a single pass and returns a new queue of the
+// Queuetype of q holding the projections for which f reports true, together with the numbers of
+// kept and dropped elements. Unlike with Map, the kept elements keep their type, priority,
+// deadlines and insertion age, so they keep their order without being sorted again, unless q has
+// a tie-breaker, which doesn't apply to the new content type. q is not changed.
+// Comparator queues can't be mapped, since their comparator doesn't apply to the new content type.
+// Locks q.
+func FilterMap[Told, Tnew any](
+	q *Queue[Told],
+	f func(Told) (Tnew, bool, error),
+) (*Queue[Tnew], int, int, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if q.order == Comparator {
+		return nil, 0, 0, ErrInvalidQueueType
+	}
+
+	kept := make([]Element[Tnew], 0, q.numElements)
+	for i, elem := range q.queueSlice {
+		c, keep, err := f(elem.Content())
+		if err != nil {
+			return nil, 0, 0, errors.Wrapf(err, "mapping element at position %d", i)
+		}
+		if keep {
+			kept = append(kept, projectElement(elem, c))
+		}
+	}
+
+	newQueue := &Queue[Tnew]{
+		order:          q.order,
+		queueSlice:     kept,
+		numElements:    len(kept),
+		maxnumElements: q.maxnumElements,
+		policy:         q.policy,
+		skipGCNil:      q.skipGCNil,
+		noShrink:       q.noShrink,
+		initialCap:     q.initialCap,
+		shrinkPolicy:   q.shrinkPolicy,
+		seq:            q.seq,
+		clock:          q.clock,
+		lock:           queueLock{disabled: q.lock.disabled},
+	}
+	if q.tieBreak != nil {
+		newQueue.rebuildInvariant()
+	}
+	return newQueue, len(kept), q.numElements - len(kept), nil
+}
+
+// projectElement returns an element with content c that keeps the type, priority, deadlines and
+// insertion sequence number of elem. Elements that are not elements of this package become
+// PriorityElements.
+func projectElement[Told, Tnew any](elem Element[Told], c Tnew) Element[Tnew] {
+	p := PriorityElement[Tnew]{
+		priority:    elem.Priority(),
+		BaseElement: BaseElement[Tnew]{content: &c, seq: sequenceOf(elem)},
+	}
+	switch e := elem.(type) {
+	case *BaseElement[Told]:
+		return &p.BaseElement
+	case *SubPriorityElement[Told]:
+		return &SubPriorityElement[Tnew]{PriorityElement: p, subPriority: e.subPriority}
+	case *ExpiringElement[Told]:
+		return &ExpiringElement[Tnew]{PriorityElement: p, expires: e.expires}
+	case *DelayedElement[Told]:
+		return &DelayedElement[Tnew]{PriorityElement: p, readyAt: e.readyAt}
+	default:
+		return &p
+	}
+}
+
+// WindowedAggregate folds every window of window consecutive elements (in removal order) with f,
+// starting from initial for each window. Returns one aggregate per window position, i.e.
+// q.Len()-window+1 aggregates.
+// If window > q.Len() a single aggregate over the full queue is returned.
+// Returns an error of type ErrInvalidWindow if window < 1 and an error of type ErrEmptyQueue if the
+// queue is empty.
+// Locks q.
+func WindowedAggregate[A, T any](q *Queue[T], window int, initial A, f func(A, T) A) ([]A, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return WindowedAggregateUnsecure(q, window, initial, f)
+}
+
+// WindowedAggregateUnsecure folds every window of window consecutive elements (in removal order)
+// with f, starting from initial for each window. Returns one aggregate per window position, i.e.
+// q.Len()-window+1 aggregates.
+// If window > q.Len() a single aggregate over the full queue is returned.
+// Returns an error of type ErrInvalidWindow if window < 1 and an error of type ErrEmptyQueue if the
+// queue is empty.
+// Does not lock q.
+func WindowedAggregateUnsecure[A, T any](
+	q *Queue[T],
+	window int,
+	initial A,
+	f func(A, T) A,
+) ([]A, error) {
+	if window < 1 {
+		return nil, ErrInvalidWindow
+	}
+	if q.numElements == 0 {
+		return nil, ErrEmptyQueue
+	}
+	if window > q.numElements {
+		window = q.numElements
+	}
+
+	ret := make([]A, 0, q.numElements-window+1)
+	// start is the slice index of the first element of the window in removal order.
+	for start := q.numElements - 1; start-window+1 >= 0; start-- {
+		aggregate := initial
+		for i := start; i > start-window; i-- {
+			aggregate = f(aggregate, q.queueSlice[i].Content())
+		}
+		ret = append(ret, aggregate)
+	}
+
+	return ret, nil
+}
+
+// GroupByReduce buckets all elements of q by key and folds the contents of each bucket with f,
+// starting from initial, in one pass in removal order.
+// Locks q.
+func GroupByReduce[T any, K comparable, A any](
+	q *Queue[T],
+	key func(T) K,
+	initial A,
+	f func(A, T) A,
+) map[K]A {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return GroupByReduceUnsecure(q, key, initial, f)
+}
+
+// GroupByReduceUnsecure buckets all elements of q by key and folds the contents of each bucket
+// with f, starting from initial, in one pass in removal order.
+// Does not lock q.
+func GroupByReduceUnsecure[T any, K comparable, A any](
+	q *Queue[T],
+	key func(T) K,
+	initial A,
+	f func(A, T) A,
+) map[K]A {
+	ret := make(map[K]A)
+	for i := q.numElements - 1; i >= 0; i-- {
+		c := q.queueSlice[i].Content()
+		k := key(c)
+		aggregate, ok := ret[k]
+		if !ok {
+			aggregate = initial
+		}
+		ret[k] = f(aggregate, c)
+	}
+	return ret
+}
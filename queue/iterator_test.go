@@ -0,0 +1,665 @@
+package queue
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestWindowedAggregate(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := []int{4, -2, 7, 0, 3, 3, 9, -5}
+	for _, c := range contents {
+		if err := q.Insert(NewBaseElement(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sum := func(a, c int) int { return a + c }
+	for window := 1; window <= len(contents)+2; window++ {
+		got, err := WindowedAggregate(q, window, 0, sum)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := window
+		if w > len(contents) {
+			w = len(contents)
+		}
+		var want []int
+		for start := 0; start+w <= len(contents); start++ {
+			s := 0
+			for _, c := range contents[start : start+w] {
+				s += c
+			}
+			want = append(want, s)
+		}
+
+		if len(got) != len(want) {
+			t.Fatalf("window %d: expected %v, got %v", window, want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("window %d: expected %v, got %v", window, want, got)
+				break
+			}
+		}
+	}
+
+	if _, err := WindowedAggregate(q, 0, 0, sum); !errors.Is(err, ErrInvalidWindow) {
+		t.Errorf("expected %v, got %v", ErrInvalidWindow, err)
+	}
+
+	empty, _ := NewQueue[int](Fifo)
+	if _, err := WindowedAggregate(empty, 1, 0, sum); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+}
+
+func TestIteratorOrderedReverse(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 6; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64(i%3))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want := drain(t, q.Clone())
+
+		var ordered, reverse []int
+		ch, cancel := q.IteratorOrdered(2)
+		for c := range ch {
+			ordered = append(ordered, c)
+		}
+		cancel()
+		ch, cancel = q.IteratorReverse(2)
+		for c := range ch {
+			reverse = append(reverse, c)
+		}
+		cancel()
+
+		if !equalContents(ordered, want) {
+			t.Errorf("queuetype %v: expected ordered %v, got %v", tp, want, ordered)
+		}
+		slices.Reverse(want)
+		if !equalContents(reverse, want) {
+			t.Errorf("queuetype %v: expected reverse %v, got %v", tp, want, reverse)
+		}
+	}
+}
+
+func TestIteratorConcurrent(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Lifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 200
+	for i := 0; i < n; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]int, 8)
+	for i := range results {
+		ch, cancel := q.Iterator(4)
+		wg.Add(1)
+		go func(i int, ch <-chan int, cancel context.CancelFunc) {
+			defer wg.Done()
+			defer cancel()
+			for c := range ch {
+				results[i] = append(results[i], c)
+			}
+		}(i, ch, cancel)
+	}
+	// mutations after the iterators were created are not part of their views.
+	for i := 0; i < n; i++ {
+		_, _, _ = q.Remove()
+		_ = q.Insert(NewBaseElement(-i))
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		if len(r) != n {
+			t.Fatalf("iterator %d: expected %d elements, got %d", i, n, len(r))
+		}
+		for j, c := range r {
+			if c != j {
+				t.Errorf("iterator %d: expected %d at %d, got %d", i, j, j, c)
+				break
+			}
+		}
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Lifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 200
+	for i := 0; i < n; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chs, cancel := q.Broadcast(1, 6)
+	defer cancel()
+	if len(chs) != 6 {
+		t.Fatalf("expected 6 channels, got %d", len(chs))
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]int, len(chs))
+	for i, ch := range chs {
+		wg.Add(1)
+		go func(i int, ch <-chan int) {
+			defer wg.Done()
+			for c := range ch {
+				results[i] = append(results[i], c)
+			}
+		}(i, ch)
+	}
+	// mutations after the broadcast was created are not part of its view.
+	for i := 0; i < n; i++ {
+		_, _, _ = q.Remove()
+		_ = q.Insert(NewBaseElement(-i))
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		if len(r) != n {
+			t.Fatalf("consumer %d: expected %d elements, got %d", i, n, len(r))
+		}
+		for j, c := range r {
+			if c != j {
+				t.Errorf("consumer %d: expected %d at %d, got %d", i, j, j, c)
+				break
+			}
+		}
+	}
+}
+
+func TestBroadcastCancel(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chs, cancel := q.Broadcast(0, 3)
+	<-chs[0]
+	cancel()
+	// every channel has to be closed after cancel, no matter how much of it was consumed.
+	for i, ch := range chs {
+		received := 0
+		for range ch {
+			received++
+		}
+		if received == 100 {
+			t.Errorf("consumer %d: received all elements after cancel", i)
+		}
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chs, cancel := q.FanOut(3, 0)
+	defer cancel()
+	for i, want := range [][]int{{0, 3, 6, 9}, {1, 4, 7}, {2, 5, 8}} {
+		var got []int
+		for c := range chs[i] {
+			got = append(got, c)
+		}
+		if !equalContents(got, want) {
+			t.Errorf("consumer %d: expected %v, got %v", i, want, got)
+		}
+	}
+
+	if chs, _ := q.FanOut(0, 0); chs != nil {
+		t.Errorf("expected no channels, got %d", len(chs))
+	}
+}
+
+func TestFanOutByKey(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := func(c int) string { return string(rune('a' + c%7)) }
+	chs, cancel := q.FanOutByKey(4, 0, key)
+	defer cancel()
+	consumer := map[string]int{}
+	received := 0
+	for i, ch := range chs {
+		last := -1
+		for c := range ch {
+			if c < last {
+				t.Errorf("consumer %d: %d after %d", i, c, last)
+			}
+			if j, ok := consumer[key(c)]; ok && j != i {
+				t.Errorf("key %s on consumers %d and %d", key(c), j, i)
+			}
+			consumer[key(c)] = i
+			last = c
+			received++
+		}
+	}
+	if received != 100 {
+		t.Errorf("expected 100 elements, got %d", received)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := q.Insert(NewPriorityElement(i, float64(i%4))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	odd, kept, dropped := q.Filter(func(c int) bool { return c%2 == 1 })
+	if kept != 5 || dropped != 5 {
+		t.Errorf("expected 5 kept and 5 dropped, got %d and %d", kept, dropped)
+	}
+	if got, want := drain(t, odd), []int{1, 5, 9, 3, 7}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if q.Len() != 10 {
+		t.Errorf("expected q to keep 10 elements, got %d", q.Len())
+	}
+}
+
+func TestFilterMap(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 10; i++ {
+			if err := q.Insert(NewPriorityElement2(i, float64(i%3), float64(i%2))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var want []string
+		for _, c := range drain(t, q.Clone()) {
+			if c%3 != 0 {
+				want = append(want, string(rune('a'+c)))
+			}
+		}
+
+		mapped, kept, dropped, err := FilterMap(q, func(c int) (string, bool, error) {
+			return string(rune('a' + c)), c%3 != 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kept != 6 || dropped != 4 {
+			t.Errorf("queuetype %v: expected 6 kept and 4 dropped, got %d and %d", tp, kept, dropped)
+		}
+		if _, ok := mapped.queueSlice[0].(*SubPriorityElement[string]); !ok {
+			t.Errorf("queuetype %v: expected a SubPriorityElement, got %T", tp, mapped.queueSlice[0])
+		}
+		if got := drain(t, mapped); !equalContents(got, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, got)
+		}
+	}
+
+	c := NewQueueFunc(func(a, b int) int { return a - b })
+	if _, _, _, err := FilterMap(c, func(c int) (int, bool, error) { return c, true, nil }); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+}
+
+func TestDrainFilter(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 10; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64(i%3))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ref := q.Clone()
+
+		even := func(c int) bool { return c%2 == 0 }
+		drained := q.DrainFilter(even)
+
+		var wantDrained, wantKept []int
+		for _, c := range drain(t, ref) {
+			if even(c) {
+				wantDrained = append(wantDrained, c)
+			} else {
+				wantKept = append(wantKept, c)
+			}
+		}
+		if !equalContents(drained, wantDrained) {
+			t.Errorf("queuetype %v: expected drained %v, got %v", tp, wantDrained, drained)
+		}
+		if got := drain(t, q); !equalContents(got, wantKept) {
+			t.Errorf("queuetype %v: expected kept %v, got %v", tp, wantKept, got)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := q.Insert(NewPriorityElement(i, float64(i%2))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := drain(t, q.Clone())
+
+	var got []int
+	for c := range q.All() {
+		got = append(got, c)
+		// the snapshot is not affected by changes to the queue.
+		_ = q.Insert(NewPriorityElement(100, 5))
+	}
+	if !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	got = got[:0]
+	for p, c := range q.Elements() {
+		if p != 5 {
+			break
+		}
+		got = append(got, c)
+	}
+	if len(got) != 5 {
+		t.Errorf("expected the 5 inserted elements before stopping, got %v", got)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityLow} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 10; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64(i%3))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want := drain(t, q.Clone())
+
+		if got := q.Drain(); !equalContents(got, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, got)
+		}
+		if q.Len() != 0 {
+			t.Errorf("queuetype %v: expected an empty queue, got length %d", tp, q.Len())
+		}
+		if got := q.Drain(); len(got) != 0 {
+			t.Errorf("queuetype %v: expected nothing from an empty queue, got %v", tp, got)
+		}
+	}
+}
+
+func TestDrainTo(t *testing.T) {
+	t.Parallel()
+	q := fifoOf(t, 1, 2)
+	buf := make([]int, 0, 8)
+	buf = append(buf, 0)
+	got := q.DrainTo(buf)
+	if want := []int{0, 1, 2}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if &got[0] != &buf[0] {
+		t.Error("expected the buffer to be reused")
+	}
+}
+
+func BenchmarkDrainFilter(b *testing.B) {
+	elems := randomPriorityElements(10000)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		q, _ := NewQueue[int](PriorityHigh)
+		q.AppendAll(elems)
+		q.RebuildInvariant()
+		b.StartTimer()
+
+		q.DrainFilter(func(c int) bool { return c%2 == 0 })
+	}
+}
+
+// BenchmarkDrainFilterRemoveLoop removes the matching elements one by one, as callers had to
+// before DrainFilter.
+func BenchmarkDrainFilterRemoveLoop(b *testing.B) {
+	elems := randomPriorityElements(10000)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		q, _ := NewQueue[int](PriorityHigh)
+		q.AppendAll(elems)
+		q.RebuildInvariant()
+		b.StartTimer()
+
+		for j := q.numElements - 1; j >= 0; j-- {
+			if q.queueSlice[j].Content()%2 == 0 {
+				_, _ = q.remove(j)
+			}
+		}
+	}
+}
+
+func TestGroupByReduce(t *testing.T) {
+	t.Parallel()
+	q := fifoOf(t, 1, 2, 3, 4, 5, 6, 7)
+	parity := func(c int) bool { return c%2 == 0 }
+
+	sums := GroupByReduce(q, parity, 0, func(a, c int) int { return a + c })
+	if len(sums) != 2 || sums[true] != 12 || sums[false] != 16 {
+		t.Errorf("expected map[false:16 true:12], got %v", sums)
+	}
+
+	words, _ := NewQueue[string](Fifo)
+	for _, w := range []string{"apple", "bob", "avocado", "cherry", "banana", "apricot"} {
+		_ = words.Insert(NewBaseElement(w))
+	}
+	counts := GroupByReduce(words, func(w string) byte { return w[0] }, 0, func(a int, _ string) int { return a + 1 })
+	if len(counts) != 3 || counts['a'] != 3 || counts['b'] != 2 || counts['c'] != 1 {
+		t.Errorf("expected 3 a, 2 b and 1 c, got %v", counts)
+	}
+
+	// the fold runs in removal order.
+	order := GroupByReduce(words, func(string) int { return 0 }, "", func(a, w string) string { return a + w[:1] })
+	if order[0] != "abacba" {
+		t.Errorf("expected abacba, got %s", order[0])
+	}
+	if q.Len() != 7 || words.Len() != 6 {
+		t.Error("expected the queues to be unchanged")
+	}
+}
+
+// receiveWithin receives from ch or fails the test after a second.
+func receiveWithin[T any](t *testing.T, ch <-chan T) (T, bool) {
+	t.Helper()
+	select {
+	case c, ok := <-ch:
+		return c, ok
+	case <-time.After(time.Second):
+		t.Fatal("nothing received in time")
+		return *new(T), false
+	}
+}
+
+func TestThrottledIterator(t *testing.T) {
+	t.Parallel()
+	// iterators stream a Fifo queue from its newest element on.
+	q := fifoOf(t, 3, 2, 1)
+	clock := newFakeClock()
+	ch, cancel := q.ThrottledIteratorWithClock(3, time.Second, clock)
+	defer cancel()
+
+	if c, _ := receiveWithin(t, ch); c != 1 {
+		t.Errorf("expected 1, got %d", c)
+	}
+	for want := 2; want <= 3; want++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second - time.Nanosecond)
+		select {
+		case c := <-ch:
+			t.Fatalf("received %d before the interval passed", c)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		clock.Advance(time.Nanosecond)
+		if c, _ := receiveWithin(t, ch); c != want {
+			t.Errorf("expected %d, got %d", want, c)
+		}
+	}
+
+	if _, ok := receiveWithin(t, ch); ok {
+		t.Errorf("expected the channel to be closed")
+	}
+}
+
+func TestThrottledIteratorCancel(t *testing.T) {
+	t.Parallel()
+	q := fifoOf(t, 3, 2, 1)
+	clock := newFakeClock()
+	ch, cancel := q.ThrottledIteratorWithClock(0, time.Hour, clock)
+
+	if c, _ := receiveWithin(t, ch); c != 1 {
+		t.Errorf("expected 1, got %d", c)
+	}
+	// the clock never advances, cancel has to interrupt the pending interval.
+	clock.BlockUntil(1)
+	cancel()
+	if c, ok := receiveWithin(t, ch); ok {
+		t.Errorf("expected the channel to be closed, got %d", c)
+	}
+}
+
+func TestMergeIterator(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		a, _ := NewQueue[int](tp)
+		b, _ := NewQueue[int](tp)
+		// even contents go to a, odd ones to b. The priority is the content divided by 10, so the
+		// queues share priorities.
+		for i := 0; i < 300; i++ {
+			q := a
+			if i%2 == 1 {
+				q = b
+			}
+			if err := q.Insert(NewPriorityElement(i, float64((i*37%300)/10))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ch, cancel := MergeIterator(a, b, 4)
+		var got []int
+		for c := range ch {
+			got = append(got, c)
+		}
+		cancel()
+
+		if len(got) != 300 {
+			t.Fatalf("queuetype %v: expected 300 elements, got %d", tp, len(got))
+		}
+		for i := 1; i < len(got); i++ {
+			prev, cur := float64((got[i-1]*37%300)/10), float64((got[i]*37%300)/10)
+			if (tp == PriorityHigh && prev < cur) || (tp == PriorityLow && prev > cur) {
+				t.Fatalf("queuetype %v: priority %v streamed before %v", tp, prev, cur)
+			}
+			// within a priority, the elements of a come first.
+			if prev == cur && got[i-1]%2 == 1 && got[i]%2 == 0 {
+				t.Fatalf("queuetype %v: %d of b streamed before %d of a", tp, got[i-1], got[i])
+			}
+		}
+		// the sources are not drained.
+		if a.Len() != 150 || b.Len() != 150 {
+			t.Errorf("queuetype %v: expected the sources to keep their elements", tp)
+		}
+	}
+}
+
+func TestMergeIteratorFifo(t *testing.T) {
+	t.Parallel()
+	ch, cancel := MergeIterator(fifoOf(t, 1, 2), fifoOf(t, 3, 4), 0)
+	defer cancel()
+
+	var got []int
+	for c := range ch {
+		got = append(got, c)
+	}
+	if want := []int{1, 2, 3, 4}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMergeIteratorCancel(t *testing.T) {
+	t.Parallel()
+	a, b := fifoOf(t), fifoOf(t)
+	for i := 0; i < 100; i++ {
+		_ = a.Insert(NewBaseElement(i))
+		_ = b.Insert(NewBaseElement(100 + i))
+	}
+	ch, cancel := MergeIterator(a, b, 0)
+	if c, _ := receiveWithin(t, ch); c != 0 {
+		t.Errorf("expected 0, got %d", c)
+	}
+	cancel()
+
+	received := 0
+	for range ch {
+		received++
+	}
+	if received == 199 {
+		t.Errorf("received all elements after cancel")
+	}
+}
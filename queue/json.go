@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// jsonQueue is the JSON form of a Queue.
+type jsonQueue[T any] struct {
+	Queuetype Queuetype `json:"queuetype"`
+	Limit     int       `json:"limit"`
+	// Elements are in insertion order.
+	Elements []jsonElement[T] `json:"elements"`
+}
+
+// jsonElement is the JSON form of an element. Priority is omitted for BaseElements, which is how
+// they are told apart from PriorityElements. SubPriority is only set for elements with a
+// sub-priority, which are decoded as SubPriorityElements.
+type jsonElement[T any] struct {
+	Priority    *float64 `json:"priority,omitempty"`
+	SubPriority *float64 `json:"subPriority,omitempty"`
+	Content     T        `json:"content"`
+}
+
+// toJSONElement converts elem to its JSON form. Elements with a sub-priority are encoded like
+// SubPriorityElements, all other elements that are not BaseElements like PriorityElements.
+func toJSONElement[T any](elem Element[T]) jsonElement[T] {
+	if _, ok := elem.(*BaseElement[T]); ok {
+		return jsonElement[T]{Content: elem.Content()}
+	}
+	priority := elem.Priority()
+	enc := jsonElement[T]{Priority: &priority, Content: elem.Content()}
+	if s, ok := elem.(subPrioritized); ok {
+		subPriority := s.SubPriority()
+		enc.SubPriority = &subPriority
+	}
+	return enc
+}
+
+// element builds the element e was encoded from.
+func (e jsonElement[T]) element() Element[T] {
+	switch {
+	case e.Priority == nil:
+		return NewBaseElement(e.Content)
+	case e.SubPriority != nil:
+		return NewPriorityElement2(e.Content, *e.Priority, *e.SubPriority)
+	default:
+		return NewPriorityElement(e.Content, *e.Priority)
+	}
+}
+
+// MarshalJSON encodes the Queuetype, limit and elements with their priorities. The contents are
+// encoded with encoding/json. The elements are written in insertion order, so that UnmarshalJSON
+// rebuilds the same removal order. Comparators and tie-breakers are not encoded.
+// Locks q.
+func (q *Queue[T]) MarshalJSON() ([]byte, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	enc := jsonQueue[T]{
+		Queuetype: q.order,
+		Limit:     q.maxnumElements,
+		Elements:  make([]jsonElement[T], q.numElements),
+	}
+	for i, elem := range q.insertionOrder() {
+		enc.Elements[i] = toJSONElement(elem)
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON replaces the Queuetype, limit and contents of q with those encoded by MarshalJSON
+// like Replace. Comparator queues can only be restored into a queue built with NewQueueFunc or
+// NewQueueWithComparator, since the comparator is not encoded. The tie-breaker and overflow policy
+// of q are kept.
+// Returns an error of type ErrCorruptData if the Queuetype or limit is invalid.
+// Locks q.
+func (q *Queue[T]) UnmarshalJSON(data []byte) error {
+	var dec jsonQueue[T]
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return errors.Wrap(err, "decoding queue")
+	}
+	elems := make([]Element[T], len(dec.Elements))
+	for i, e := range dec.Elements {
+		elems[i] = e.element()
+	}
+	return q.restore(dec.Queuetype, dec.Limit, elems)
+}
+
+// MarshalJSON encodes the priority and content of e.
+func (e PriorityElement[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonElement[T]{Priority: &e.priority, Content: e.Content()})
+}
+
+// UnmarshalJSON decodes the priority and content encoded by MarshalJSON into e.
+func (e *PriorityElement[T]) UnmarshalJSON(data []byte) error {
+	var dec jsonElement[T]
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return errors.Wrap(err, "decoding element")
+	}
+	if dec.Priority != nil {
+		e.priority = *dec.Priority
+	}
+	e.SetContent(dec.Content)
+	return nil
+}
+
+// MarshalJSON encodes the priority, sub-priority and content of e.
+func (e SubPriorityElement[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonElement[T]{Priority: &e.priority, SubPriority: &e.subPriority, Content: e.Content()})
+}
+
+// UnmarshalJSON decodes the priority, sub-priority and content encoded by MarshalJSON into e.
+func (e *SubPriorityElement[T]) UnmarshalJSON(data []byte) error {
+	var dec jsonElement[T]
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return errors.Wrap(err, "decoding element")
+	}
+	if dec.Priority != nil {
+		e.priority = *dec.Priority
+	}
+	if dec.SubPriority != nil {
+		e.subPriority = *dec.SubPriority
+	}
+	e.SetContent(dec.Content)
+	return nil
+}
+
+// MarshalJSON encodes the content of e.
+func (e BaseElement[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonElement[T]{Content: e.Content()})
+}
+
+// UnmarshalJSON decodes the content encoded by MarshalJSON into e.
+func (e *BaseElement[T]) UnmarshalJSON(data []byte) error {
+	var dec jsonElement[T]
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return errors.Wrap(err, "decoding element")
+	}
+	e.SetContent(dec.Content)
+	return nil
+}
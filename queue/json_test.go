@@ -0,0 +1,167 @@
+package queue
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh, PriorityLow, FifoLimited, LRU} {
+		q, err := NewQueue[string](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = q.SetLimit(4)
+		for i, c := range []string{"a", "b", "c", "d", "e"} {
+			if err := q.Insert(NewPriorityElement(c, float64(i%2))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := q.Insert(NewBaseElement("f")); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := json.Marshal(q)
+		if err != nil {
+			t.Fatalf("queuetype %v: %v", tp, err)
+		}
+		var restored Queue[string]
+		if err := json.Unmarshal(data, &restored); err != nil {
+			t.Fatalf("queuetype %v: %v", tp, err)
+		}
+
+		want := q.Snapshot()
+		got := restored.Snapshot()
+		if got.Len() != want.Len() {
+			t.Fatalf("queuetype %v: expected length %d, got %d", tp, want.Len(), got.Len())
+		}
+		for i := 0; i < want.Len(); i++ {
+			wp, wc, _ := want.At(i)
+			gp, gc, _ := got.At(i)
+			if wp != gp || wc != gc {
+				t.Errorf("queuetype %v: expected (%v, %s) at %d, got (%v, %s)", tp, wp, wc, i, gp, gc)
+			}
+		}
+		// the limit is restored as well.
+		_ = restored.Insert(NewBaseElement("g"))
+		if tp == FifoLimited && restored.Len() != 4 {
+			t.Errorf("expected the limit to be restored, got length %d", restored.Len())
+		}
+	}
+}
+
+func TestJSONSubPriority(t *testing.T) {
+	t.Parallel()
+	q, _ := NewQueue[string](PriorityHigh)
+	for _, elem := range []Element[string]{
+		NewPriorityElement2("a", 1, 1),
+		NewPriorityElement2("b", 1, 3),
+		NewPriorityElement2("c", 1, 2),
+		NewPriorityElement("d", 2),
+	} {
+		if err := q.Insert(elem); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Queue[string]
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := drain(t, &restored), []string{"d", "b", "c", "a"}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	data, err = json.Marshal(NewPriorityElement2("e", 1, 2.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"priority":1,"subPriority":2.5,"content":"e"}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+	var s SubPriorityElement[string]
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Priority() != 1 || s.SubPriority() != 2.5 || s.Content() != "e" {
+		t.Errorf("expected (1, 2.5, e), got (%v, %v, %s)", s.Priority(), s.SubPriority(), s.Content())
+	}
+}
+
+func TestJSONComparator(t *testing.T) {
+	t.Parallel()
+	cmp := func(a, b int) int { return a - b }
+	q := NewQueueFunc(cmp)
+	for _, c := range []int{3, 1, 2} {
+		_ = q.Insert(NewBaseElement(c))
+	}
+	data, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var plain Queue[int]
+	if err := json.Unmarshal(data, &plain); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+	restored := NewQueueFunc(cmp)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := drain(t, restored), []int{1, 2, 3}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestJSONCorrupt(t *testing.T) {
+	t.Parallel()
+	for _, data := range []string{
+		`{"queuetype": 99, "limit": 0, "elements": []}`,
+		`{"queuetype": 0, "limit": -1, "elements": []}`,
+	} {
+		var q Queue[int]
+		if err := json.Unmarshal([]byte(data), &q); !errors.Is(err, ErrCorruptData) {
+			t.Errorf("%s: expected %v, got %v", data, ErrCorruptData, err)
+		}
+	}
+}
+
+func TestJSONElements(t *testing.T) {
+	t.Parallel()
+	data, err := json.Marshal(NewPriorityElement("a", 1.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"priority":1.5,"content":"a"}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+	var p PriorityElement[string]
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Priority() != 1.5 || p.Content() != "a" {
+		t.Errorf("expected (1.5, a), got (%v, %s)", p.Priority(), p.Content())
+	}
+
+	data, err = json.Marshal(NewBaseElement("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"content":"b"}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+	var b BaseElement[string]
+	if err := json.Unmarshal(data, &b); err != nil {
+		t.Fatal(err)
+	}
+	if b.Content() != "b" {
+		t.Errorf("expected b, got %s", b.Content())
+	}
+}
@@ -0,0 +1,122 @@
+package queue
+
+import "github.com/pkg/errors"
+
+// KeyedPriorityQueue is a priority queue that holds at most one element per key. Inserting content
+// whose key is already present keeps only the better of the two priorities, higher for
+// PriorityHigh and lower for PriorityLow queues, as needed for the frontier of Dijkstra's
+// algorithm or A*. The keys are kept in an index, so the lookup takes O(1).
+type KeyedPriorityQueue[K comparable, T any] struct {
+	q     *Queue[T]
+	key   func(T) K
+	index map[K]Element[T]
+}
+
+// NewKeyedPriorityQueue builds a new KeyedPriorityQueue of Queuetype order that keys its contents
+// by key. Only PriorityHigh and PriorityLow are supported, for all other Queuetypes
+// ErrInvalidQueueType is returned.
+func NewKeyedPriorityQueue[K comparable, T any](
+	key func(T) K,
+	order Queuetype,
+) (*KeyedPriorityQueue[K, T], error) {
+	if order != PriorityHigh && order != PriorityLow {
+		return nil, ErrInvalidQueueType
+	}
+	q, err := NewQueue[T](order)
+	if err != nil {
+		return nil, errors.Wrap(err, "building keyed queue")
+	}
+	return &KeyedPriorityQueue[K, T]{
+		q:     q,
+		key:   key,
+		index: make(map[K]Element[T]),
+	}, nil
+}
+
+// Len returns the number of elements in the queue, which is the number of distinct keys.
+func (k *KeyedPriorityQueue[K, T]) Len() int {
+	return k.q.Len()
+}
+
+// Contains reports whether the queue holds an element with key.
+func (k *KeyedPriorityQueue[K, T]) Contains(key K) bool {
+	k.q.lock.Lock()
+	defer k.q.lock.Unlock()
+
+	_, ok := k.index[key]
+	return ok
+}
+
+// Insert inserts content with priority, unless the queue already holds an element with the same
+// key. In that case the element is only changed if priority is better than its current one: it
+// takes over content and priority and is moved as if it was freshly inserted.
+// Returns whether the queue changed.
+func (k *KeyedPriorityQueue[K, T]) Insert(content T, priority float64) (bool, error) {
+	k.q.lock.Lock()
+	defer k.q.lock.Unlock()
+
+	key := k.key(content)
+	existing, ok := k.index[key]
+	if !ok {
+		elem := NewPriorityElement(content, priority)
+		if err := k.q.insert(elem); err != nil {
+			return false, errors.Wrap(err, "inserting keyed element")
+		}
+		k.index[key] = elem
+		return true, nil
+	}
+
+	old := existing.Priority()
+	if old == priority || (priority > old) != (k.q.order == PriorityHigh) {
+		return false, nil
+	}
+	if err := k.detach(existing); err != nil {
+		return false, err
+	}
+	existing.SetContent(content)
+	existing.SetPriority(priority)
+	k.q.stamp(existing)
+	if _, err := k.q.place(existing); err != nil {
+		delete(k.index, key)
+		return false, errors.Wrap(err, "reinserting keyed element")
+	}
+	return true, nil
+}
+
+// detach takes elem out of the slice of the queue without counting a removal. elem is found by a
+// binary search for its priority.
+// Does not lock k.
+func (k *KeyedPriorityQueue[K, T]) detach(elem Element[T]) error {
+	lo, hi := k.q.priorityBlock(elem.Priority())
+	for i := lo; i < hi; i++ {
+		if k.q.queueSlice[i] != elem {
+			continue
+		}
+		if _, err := k.q.deleteWithoutMemoryManagement(i); err != nil {
+			return errors.Wrap(err, "detaching keyed element")
+		}
+		return nil
+	}
+	// the index and the queue are only changed together under lock, so this can't happen.
+	return errors.Wrap(ErrIndexOutOfBounds, "keyed element is not in its priority block")
+}
+
+// Remove pops the element with the best priority like Queue.Remove and drops its key, so it can be
+// inserted again.
+func (k *KeyedPriorityQueue[K, T]) Remove() (T, float64, error) {
+	k.q.lock.Lock()
+	defer k.q.lock.Unlock()
+
+	elem, err := k.q.removeHead()
+	if err != nil {
+		return *new(T), 0, err
+	}
+	delete(k.index, k.key(elem.Content()))
+	return elem.Content(), elem.Priority(), nil
+}
+
+// PeekElem returns a copy of the elem that would be returned on a call to Remove().
+// Returns an error of type ErrEmptyQueue when the list is empty.
+func (k *KeyedPriorityQueue[K, T]) PeekElem() (float64, T, error) {
+	return k.q.PeekElem()
+}
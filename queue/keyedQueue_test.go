@@ -0,0 +1,90 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type frontierEntry struct {
+	node string
+	via  string
+}
+
+func TestKeyedPriorityQueue(t *testing.T) {
+	t.Parallel()
+	byNode := func(e frontierEntry) string { return e.node }
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		k, err := NewKeyedPriorityQueue(byNode, tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// better is the priority that wins over p for the queuetype, worse the one that loses.
+		better := func(p float64) float64 {
+			if tp == PriorityHigh {
+				return p + 10
+			}
+			return p - 10
+		}
+		worse := func(p float64) float64 { return 2*p - better(p) }
+
+		steps := []struct {
+			entry    frontierEntry
+			priority float64
+			changed  bool
+		}{
+			{frontierEntry{"a", "start"}, 5, true},
+			{frontierEntry{"b", "start"}, 6, true},
+			{frontierEntry{"c", "start"}, 7, true},
+			{frontierEntry{"a", "b"}, better(5), true},
+			{frontierEntry{"b", "c"}, worse(6), false},
+			{frontierEntry{"c", "c"}, 7, false},
+			{frontierEntry{"a", "c"}, worse(better(5)), false},
+		}
+		for _, s := range steps {
+			changed, err := k.Insert(s.entry, s.priority)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != s.changed {
+				t.Errorf("queuetype %v, %v with %v: expected changed %v, got %v", tp, s.entry, s.priority, s.changed, changed)
+			}
+		}
+
+		if k.Len() != 3 {
+			t.Fatalf("queuetype %v: expected one element per key, got %d", tp, k.Len())
+		}
+		want := []struct {
+			entry    frontierEntry
+			priority float64
+		}{
+			{frontierEntry{"a", "b"}, better(5)},
+			{frontierEntry{"c", "start"}, 7},
+			{frontierEntry{"b", "start"}, 6},
+		}
+		if tp == PriorityLow {
+			want[1], want[2] = want[2], want[1]
+		}
+		for _, w := range want {
+			got, prio, err := k.Remove()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != w.entry || prio != w.priority {
+				t.Errorf("queuetype %v: expected %v with %v, got %v with %v", tp, w.entry, w.priority, got, prio)
+			}
+			if k.Contains(w.entry.node) {
+				t.Errorf("queuetype %v: expected %s to be dropped from the index", tp, w.entry.node)
+			}
+		}
+
+		// removed keys can be inserted again.
+		if changed, _ := k.Insert(frontierEntry{"a", "again"}, 1); !changed {
+			t.Errorf("queuetype %v: expected a removed key to be admitted again", tp)
+		}
+	}
+
+	if _, err := NewKeyedPriorityQueue(byNode, Fifo); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+}
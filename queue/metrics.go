@@ -0,0 +1,146 @@
+package queue
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// QueueMetrics holds the counters of the operations a queue performed since it was built.
+type QueueMetrics struct {
+	// Inserts counts the elements that were inserted or appended.
+	Inserts uint64
+
+	// Removes counts the elements that were removed, not including evictions.
+	Removes uint64
+
+	// Shrinks counts the reallocations of the backing slice to a smaller capacity.
+	Shrinks uint64
+
+	// Grows counts the reallocations of the backing slice to a larger capacity.
+	Grows uint64
+
+	// Evictions counts the elements that were dropped because a FifoLimited or LRU queue was
+	// full.
+	Evictions uint64
+
+	// Expirations counts the expired elements that were dropped.
+	Expirations uint64
+}
+
+// queueCounters are the counters behind QueueMetrics. They are only written while q.lock is held,
+// but are atomic so that Metrics does not need to lock.
+type queueCounters struct {
+	inserts     atomic.Uint64
+	removes     atomic.Uint64
+	shrinks     atomic.Uint64
+	grows       atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+
+	// highWater is the largest number of elements the queue held.
+	highWater atomic.Uint64
+	// dwellTotal sums up the nanoseconds the elements that left the queue spent in it, dwellCount
+	// counts them. Only written while time in queue is tracked.
+	dwellTotal atomic.Int64
+	dwellCount atomic.Uint64
+}
+
+// QueueStats extends QueueMetrics by the state of a queue.
+type QueueStats struct {
+	QueueMetrics
+
+	// Len is the current number of elements.
+	Len int
+
+	// HighWaterMark is the largest number of elements the queue held at once.
+	HighWaterMark int
+
+	// AvgTimeInQueue is the average time the elements that left the queue spent in it, measured
+	// with the clock of the queue. It is only measured since SetTrackTimeInQueue enabled it and 0
+	// until an element left.
+	AvgTimeInQueue time.Duration
+}
+
+// Metrics returns the current operation counters of the queue.
+func (q *Queue[T]) Metrics() QueueMetrics {
+	return QueueMetrics{
+		Inserts:     q.counters.inserts.Load(),
+		Removes:     q.counters.removes.Load(),
+		Shrinks:     q.counters.shrinks.Load(),
+		Grows:       q.counters.grows.Load(),
+		Evictions:   q.counters.evictions.Load(),
+		Expirations: q.counters.expirations.Load(),
+	}
+}
+
+// Stats returns the current operation counters and state of the queue.
+// Locks q.
+func (q *Queue[T]) Stats() QueueStats {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	stats := QueueStats{
+		QueueMetrics:  q.Metrics(),
+		Len:           q.numElements,
+		HighWaterMark: int(q.counters.highWater.Load()),
+	}
+	if n := q.counters.dwellCount.Load(); n > 0 {
+		stats.AvgTimeInQueue = time.Duration(q.counters.dwellTotal.Load() / int64(n))
+	}
+	return stats
+}
+
+// SetTrackTimeInQueue determines whether the queue measures the time its elements spend in it,
+// for AvgTimeInQueue of Stats. This is disabled by default, since it stores the insertion time of
+// every element. Elements that are already in the queue count as inserted now.
+// Locks q.
+func (q *Queue[T]) SetTrackTimeInQueue(enabled bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !enabled {
+		q.insertedAt = nil
+		return
+	}
+	if q.insertedAt != nil {
+		return
+	}
+	q.insertedAt = make(map[Element[T]]time.Time, q.numElements)
+	now := q.now()
+	for _, elem := range q.queueSlice[:q.numElements] {
+		q.insertedAt[elem] = now
+	}
+}
+
+// countLen raises the high-water mark to the current number of elements.
+func (q *Queue[T]) countLen() {
+	if n := uint64(q.numElements); n > q.counters.highWater.Load() {
+		q.counters.highWater.Store(n)
+	}
+}
+
+// reset sets all counters back to zero.
+func (c *queueCounters) reset() {
+	c.inserts.Store(0)
+	c.removes.Store(0)
+	c.shrinks.Store(0)
+	c.grows.Store(0)
+	c.evictions.Store(0)
+	c.expirations.Store(0)
+	c.highWater.Store(0)
+	c.dwellTotal.Store(0)
+	c.dwellCount.Store(0)
+}
+
+// countGrow counts a grow of the backing slice if its capacity exceeds capBefore, which is the
+// backingCap before the operation, and the number of elements for the high-water mark. It also
+// releases fifoBuf once queueSlice moved to another array.
+func (q *Queue[T]) countGrow(capBefore int) {
+	q.countLen()
+	if !q.sharesFifoBuf() {
+		q.fifoBuf = nil
+	}
+	if q.backingCap() > capBefore {
+		q.counters.grows.Add(1)
+	}
+}
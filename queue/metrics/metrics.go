@@ -0,0 +1,133 @@
+// Package metrics exposes the statistics of queues to monitoring systems: as expvar variables and
+// in the Prometheus text exposition format, so that operators can graph queue depth and
+// throughput.
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/beeemT/Datastructures-and-Algorithms/queue"
+	"github.com/pkg/errors"
+)
+
+// ErrDuplicateName is returned when a queue is registered under a name that is already taken.
+var ErrDuplicateName = errors.New("a queue with this name is already registered")
+
+// Source provides the statistics of a queue. It is implemented by *queue.Queue[T] for all T.
+type Source interface {
+	Stats() queue.QueueStats
+}
+
+// Registry holds named queues and reads their statistics whenever they are exported.
+type Registry struct {
+	lock    sync.Mutex
+	sources map[string]Source
+}
+
+// NewRegistry builds an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{sources: make(map[string]Source)}
+}
+
+// Register adds src under name. Returns ErrDuplicateName if name is taken.
+func (r *Registry) Register(name string, src Source) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.sources[name]; ok {
+		return errors.Wrapf(ErrDuplicateName, "registering queue %q", name)
+	}
+	r.sources[name] = src
+	return nil
+}
+
+// Unregister removes the queue registered under name, if there is one.
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.sources, name)
+}
+
+// Stats returns the current statistics of all registered queues by name.
+func (r *Registry) Stats() map[string]queue.QueueStats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	stats := make(map[string]queue.QueueStats, len(r.sources))
+	for name, src := range r.sources {
+		stats[name] = src.Stats()
+	}
+	return stats
+}
+
+// PublishExpvar publishes the statistics of all registered queues as the expvar variable name,
+// which is served as JSON on /debug/vars. Queues registered later are included as well.
+// Like expvar.Publish it panics if name is already published.
+func (r *Registry) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return r.Stats() }))
+}
+
+// metric describes one metric of the Prometheus exposition.
+type metric struct {
+	name, help, kind string
+	value            func(queue.QueueStats) float64
+}
+
+var metricFamilies = []metric{
+	{"queue_length", "Current number of elements.", "gauge",
+		func(s queue.QueueStats) float64 { return float64(s.Len) }},
+	{"queue_high_water_mark", "Largest number of elements held at once.", "gauge",
+		func(s queue.QueueStats) float64 { return float64(s.HighWaterMark) }},
+	{"queue_avg_time_in_queue_seconds", "Average time the removed elements spent in the queue.", "gauge",
+		func(s queue.QueueStats) float64 { return s.AvgTimeInQueue.Seconds() }},
+	{"queue_inserts_total", "Inserted elements.", "counter",
+		func(s queue.QueueStats) float64 { return float64(s.Inserts) }},
+	{"queue_removes_total", "Removed elements, not including evictions.", "counter",
+		func(s queue.QueueStats) float64 { return float64(s.Removes) }},
+	{"queue_evictions_total", "Elements dropped because the queue was full.", "counter",
+		func(s queue.QueueStats) float64 { return float64(s.Evictions) }},
+	{"queue_expirations_total", "Expired elements that were dropped.", "counter",
+		func(s queue.QueueStats) float64 { return float64(s.Expirations) }},
+	{"queue_grows_total", "Reallocations of the backing slice to a larger capacity.", "counter",
+		func(s queue.QueueStats) float64 { return float64(s.Grows) }},
+	{"queue_shrinks_total", "Reallocations of the backing slice to a smaller capacity.", "counter",
+		func(s queue.QueueStats) float64 { return float64(s.Shrinks) }},
+}
+
+// WritePrometheus writes the statistics of all registered queues to w in the Prometheus text
+// exposition format, one time series per queue labeled with queue="<name>".
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	stats := r.Stats()
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, m := range metricFamilies {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, name := range names {
+			fmt.Fprintf(&b, "%s{queue=\"%s\"} %g\n", m.name, labelEscaper.Replace(name), m.value(stats[name]))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return errors.Wrap(err, "writing metrics")
+}
+
+// labelEscaper escapes label values as required by the exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// ServeHTTP serves the statistics in the Prometheus text exposition format, so that the Registry
+// can be mounted as the scrape endpoint, e.g. on /metrics.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.WritePrometheus(w)
+}
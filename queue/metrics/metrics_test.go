@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/beeemT/Datastructures-and-Algorithms/queue"
+	"github.com/pkg/errors"
+)
+
+func newRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	for _, name := range []string{"jobs", `odd "name"`} {
+		q, err := queue.NewQueue[int](queue.Fifo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			if err := q.Insert(queue.NewBaseElement(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, _, err := q.Remove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Register(name, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+	r := newRegistry(t)
+	q, _ := queue.NewQueue[int](queue.Lifo)
+	if err := r.Register("jobs", q); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("expected %v, got %v", ErrDuplicateName, err)
+	}
+	r.Unregister("jobs")
+	if err := r.Register("jobs", q); err != nil {
+		t.Error(err)
+	}
+	if got := r.Stats()["jobs"].Len; got != 0 {
+		t.Errorf("expected length 0, got %d", got)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+	rec := httptest.NewRecorder()
+	newRegistry(t).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, line := range []string{
+		"# TYPE queue_length gauge",
+		`queue_length{queue="jobs"} 2`,
+		`queue_inserts_total{queue="odd \"name\""} 3`,
+		"# TYPE queue_removes_total counter",
+		`queue_removes_total{queue="jobs"} 1`,
+		`queue_high_water_mark{queue="jobs"} 3`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("missing %q in\n%s", line, body)
+		}
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	t.Parallel()
+	newRegistry(t).PublishExpvar("test_queues")
+
+	var stats map[string]queue.QueueStats
+	if err := json.Unmarshal([]byte(expvar.Get("test_queues").String()), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if got := stats["jobs"].Len; got != 2 {
+		t.Errorf("expected length 2, got %d", got)
+	}
+}
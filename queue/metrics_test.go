@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Lifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Append(NewBaseElement(10))
+	for i := 0; i < 9; i++ {
+		if _, _, err := q.Remove(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.DrainFilter(func(int) bool { return true })
+
+	m := q.Metrics()
+	// appending to an empty slice grows it to capacities 1, 2, 4, 8 and 16.
+	want := QueueMetrics{Inserts: 11, Removes: 11, Grows: 5}
+	if m.Inserts != want.Inserts || m.Removes != want.Removes || m.Grows != want.Grows || m.Evictions != 0 {
+		t.Errorf("expected %+v, got %+v", want, m)
+	}
+	if m.Shrinks == 0 {
+		t.Errorf("expected shrinks after emptying the queue, got %+v", m)
+	}
+
+	limited, _ := NewQueue[int](FifoLimited)
+	if err := limited.SetLimit(2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := limited.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m := limited.Metrics(); m.Inserts != 5 || m.Evictions != 3 || m.Removes != 0 {
+		t.Errorf("expected 5 inserts and 3 evictions, got %+v", m)
+	}
+}
+
+func TestMetricsConcurrent(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, ops = 8, 200
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				_ = q.Insert(NewBaseElement(i))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				_ = q.Metrics()
+			}
+		}()
+	}
+	wg.Wait()
+
+	drain(t, q)
+	if m := q.Metrics(); m.Inserts != workers*ops || m.Removes != workers*ops {
+		t.Errorf("expected %d inserts and removes, got %+v", workers*ops, m)
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := newFakeClock()
+	q.SetClock(clock)
+	if err := q.Insert(NewBaseElement(0)); err != nil {
+		t.Fatal(err)
+	}
+	// the element that is already in the queue counts as inserted now.
+	q.SetTrackTimeInQueue(true)
+	for i := 1; i < 3; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock.Advance(10 * time.Second)
+	if _, _, err := q.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(10 * time.Second)
+	if _, _, err := q.Remove(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := q.Stats()
+	if stats.Len != 1 || stats.HighWaterMark != 3 || stats.Inserts != 3 || stats.Removes != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if stats.AvgTimeInQueue != 15*time.Second {
+		t.Errorf("expected average time in queue 15s, got %v", stats.AvgTimeInQueue)
+	}
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// cacheLineSize is the assumed size of a CPU cache line. The positions of a MPMCQueue are padded
+// to it, so that producers and consumers don't invalidate each other's cache lines.
+const cacheLineSize = 64
+
+// MPMCQueue is a bounded lock-free FIFO queue for any number of concurrent producers and
+// consumers, for pipelines where the lock of Queue becomes the bottleneck. Insert and Remove
+// never block and never allocate.
+// It is a ring buffer of slots that are claimed by compare-and-swap on the insert and remove
+// positions (D. Vyukov's bounded MPMC queue). Every slot carries a sequence number that tells
+// whether it is free for the producer of a position or filled for its consumer.
+type MPMCQueue[T any] struct {
+	_    [cacheLineSize]byte
+	head atomic.Uint64 // position of the next Remove
+	_    [cacheLineSize - 8]byte
+	tail atomic.Uint64 // position of the next Insert
+	_    [cacheLineSize - 8]byte
+
+	mask  uint64
+	slots []mpmcSlot[T]
+}
+
+type mpmcSlot[T any] struct {
+	// seq equals the position a producer may fill the slot for, or that position + 1 once the
+	// slot holds its content.
+	seq     atomic.Uint64
+	content T
+}
+
+// NewMPMCQueue builds an empty MPMCQueue that holds up to capacity elements. The capacity is
+// rounded up to the next power of two.
+// Returns ErrInvalidQueueLimit if capacity < 1.
+func NewMPMCQueue[T any](capacity int) (*MPMCQueue[T], error) {
+	if capacity < 1 || capacity > 1<<(bits.UintSize-2) {
+		return nil, ErrInvalidQueueLimit
+	}
+	size := uint64(1) << bits.Len64(uint64(capacity-1))
+	q := &MPMCQueue[T]{
+		mask:  size - 1,
+		slots: make([]mpmcSlot[T], size),
+	}
+	for i := range q.slots {
+		q.slots[i].seq.Store(uint64(i))
+	}
+	return q, nil
+}
+
+// Insert appends c to the queue.
+// Returns ErrQueueFull if all slots are taken. A slot is only free again once the consumer that
+// claimed it has taken its content, so the queue may report to be full for a moment while a
+// Remove is in progress.
+func (q *MPMCQueue[T]) Insert(c T) error {
+	pos := q.tail.Load()
+	for {
+		slot := &q.slots[pos&q.mask]
+		seq := slot.seq.Load()
+		switch diff := int64(seq - pos); {
+		case diff == 0:
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				slot.content = c
+				slot.seq.Store(pos + 1)
+				return nil
+			}
+			pos = q.tail.Load()
+		case diff < 0:
+			// the slot still holds the content of the previous round.
+			return ErrQueueFull
+		default:
+			// another producer claimed pos.
+			pos = q.tail.Load()
+		}
+	}
+}
+
+// Remove pops the oldest content of the queue.
+// Returns ErrEmptyQueue if the queue holds no content. Like for Insert, a content whose Insert is
+// still in progress is not visible yet, so the queue may report to be empty for a moment.
+func (q *MPMCQueue[T]) Remove() (T, error) {
+	pos := q.head.Load()
+	for {
+		slot := &q.slots[pos&q.mask]
+		seq := slot.seq.Load()
+		switch diff := int64(seq - (pos + 1)); {
+		case diff == 0:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				c := slot.content
+				slot.content = *new(T) // release the content for the GC
+				// free the slot for the producer of the next round.
+				slot.seq.Store(pos + q.mask + 1)
+				return c, nil
+			}
+			pos = q.head.Load()
+		case diff < 0:
+			return *new(T), ErrEmptyQueue
+		default:
+			// another consumer claimed pos.
+			pos = q.head.Load()
+		}
+	}
+}
+
+// Len returns the number of elements in the queue. Under concurrent use it is only a snapshot,
+// which includes the Inserts and Removes in progress.
+func (q *MPMCQueue[T]) Len() int {
+	// head is loaded first, so that tail can't fall behind it.
+	head := q.head.Load()
+	tail := q.tail.Load()
+	return int(tail - head)
+}
+
+// Capacity returns the number of elements the queue can hold.
+func (q *MPMCQueue[T]) Capacity() int {
+	return len(q.slots)
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestMPMCQueueSequential(t *testing.T) {
+	t.Parallel()
+	if _, err := NewMPMCQueue[int](0); !errors.Is(err, ErrInvalidQueueLimit) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueLimit, err)
+	}
+	q, err := NewMPMCQueue[int](3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Capacity() != 4 {
+		t.Errorf("expected capacity 4, got %d", q.Capacity())
+	}
+
+	// wrap around the ring a few times.
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 4; i++ {
+			if err := q.Insert(round*4 + i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := q.Insert(-1); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("expected %v, got %v", ErrQueueFull, err)
+		}
+		if q.Len() != 4 {
+			t.Errorf("expected length 4, got %d", q.Len())
+		}
+		for i := 0; i < 4; i++ {
+			c, err := q.Remove()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c != round*4+i {
+				t.Errorf("expected %d, got %d", round*4+i, c)
+			}
+		}
+		if _, err := q.Remove(); !errors.Is(err, ErrEmptyQueue) {
+			t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+		}
+	}
+}
+
+func TestMPMCQueueConcurrent(t *testing.T) {
+	t.Parallel()
+	const producers, consumers, perProducer = 4, 4, 500
+	q, err := NewMPMCQueue[int](64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				for q.Insert(p*perProducer+i) != nil {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+
+	seen := make([][]int, consumers)
+	var consumed sync.WaitGroup
+	remaining := make(chan struct{}, producers*perProducer)
+	for i := 0; i < producers*perProducer; i++ {
+		remaining <- struct{}{}
+	}
+	close(remaining)
+	for c := 0; c < consumers; c++ {
+		consumed.Add(1)
+		go func(c int) {
+			defer consumed.Done()
+			for range remaining {
+				for {
+					v, err := q.Remove()
+					if err == nil {
+						seen[c] = append(seen[c], v)
+						break
+					}
+					runtime.Gosched()
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+	consumed.Wait()
+
+	counts := make([]int, producers*perProducer)
+	for c, values := range seen {
+		// contents of the same producer are removed in their insertion order.
+		last := make([]int, producers)
+		for i := range last {
+			last[i] = -1
+		}
+		for _, v := range values {
+			counts[v]++
+			if p := v / perProducer; v <= last[p] {
+				t.Errorf("consumer %d: %d removed after %d", c, v, last[p])
+			} else {
+				last[p] = v
+			}
+		}
+	}
+	for v, n := range counts {
+		if n != 1 {
+			t.Errorf("%d removed %d times", v, n)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package queue
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MultiQueue groups its elements by a key, e.g. a tenant ID, into sub-queues of one Queuetype and
+// serves Remove from the sub-queues in turn, so that a producer that floods its sub-queue can't
+// starve the others. Each key holds its turn for as many removals as its weight, which is 1 unless
+// set with SetWeight, so with equal weights the keys are served round-robin and otherwise in
+// weighted-fair order.
+// Within a sub-queue the elements are removed in the order of the Queuetype.
+type MultiQueue[K comparable, T any] struct {
+	lock    sync.Mutex
+	order   Queuetype
+	key     func(T) K
+	subs    map[K]*Queue[T]
+	weights map[K]int
+	// active holds the keys of the non-empty sub-queues in the order they are served.
+	active []K
+	next   int
+	// served counts the removals of the current turn of active[next].
+	served int
+}
+
+// NewMultiQueue builds an empty MultiQueue that groups its elements by key into sub-queues of
+// Queuetype tp. Returns ErrInvalidQueueType for nonexistent Queuetypes and Comparator.
+func NewMultiQueue[K comparable, T any](key func(T) K, tp Queuetype) (*MultiQueue[K, T], error) {
+	if _, err := NewQueue[T](tp); err != nil {
+		return nil, errors.Wrap(err, "building multi queue")
+	}
+	return &MultiQueue[K, T]{
+		order:   tp,
+		key:     key,
+		subs:    make(map[K]*Queue[T]),
+		weights: make(map[K]int),
+	}, nil
+}
+
+// SetWeight sets the number of elements that are removed for key in a row before the next key is
+// served. The weight also applies to sub-queues of key created later.
+// Returns ErrInvalidWeight if weight < 1.
+func (m *MultiQueue[K, T]) SetWeight(key K, weight int) error {
+	if weight < 1 {
+		return ErrInvalidWeight
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if weight == 1 {
+		delete(m.weights, key)
+	} else {
+		m.weights[key] = weight
+	}
+	return nil
+}
+
+// Insert inserts elem into the sub-queue of its key like Queue.Insert. A key that has no elements
+// yet is served after all other keys with elements.
+func (m *MultiQueue[K, T]) Insert(elem Element[T]) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := m.key(elem.Content())
+	sub, ok := m.subs[key]
+	if !ok {
+		// the sub-queues are only used under the lock of m.
+		sub, _ = NewQueueUnsecure[T](m.order)
+	}
+	if err := sub.Insert(elem); err != nil {
+		return err
+	}
+	if !ok {
+		m.subs[key] = sub
+		m.activate(key)
+	}
+	return nil
+}
+
+// activate adds key to the keys that are served, right before the key whose turn it is, so that
+// it is served last.
+// Does not lock m.
+func (m *MultiQueue[K, T]) activate(key K) {
+	if len(m.active) == 0 {
+		m.active = append(m.active, key)
+		return
+	}
+	m.active = append(m.active[:m.next+1], m.active[m.next:]...)
+	m.active[m.next] = key
+	m.next++
+}
+
+// deactivate drops the key whose turn it is together with its sub-queue and passes the turn on.
+// Does not lock m.
+func (m *MultiQueue[K, T]) deactivate() {
+	delete(m.subs, m.active[m.next])
+	m.active = append(m.active[:m.next], m.active[m.next+1:]...)
+	m.served = 0
+	if m.next == len(m.active) {
+		m.next = 0
+	}
+}
+
+// passTurn passes the turn on to the next key.
+// Does not lock m.
+func (m *MultiQueue[K, T]) passTurn() {
+	m.served = 0
+	m.next = (m.next + 1) % len(m.active)
+}
+
+// Remove removes the next element of the key whose turn it is and passes the turn on once the key
+// used up its weight or its elements. Keys of Delayed sub-queues whose head is not ready are
+// skipped.
+// If the queue is empty, ErrEmptyQueue is returned, if no element is ready, ErrNotReady.
+func (m *MultiQueue[K, T]) Remove() (K, T, float64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for tries := len(m.active); tries > 0; tries-- {
+		key := m.active[m.next]
+		content, priority, err := m.subs[key].Remove()
+		switch {
+		case errors.Is(err, ErrNotReady):
+			m.passTurn()
+			continue
+		case errors.Is(err, ErrEmptyQueue):
+			// the remaining elements expired.
+			m.deactivate()
+			continue
+		case err != nil:
+			return key, content, priority, err
+		}
+
+		m.served++
+		if m.subs[key].Len() == 0 {
+			m.deactivate()
+		} else if m.served >= m.weight(key) {
+			m.passTurn()
+		}
+		return key, content, priority, nil
+	}
+
+	if len(m.active) > 0 {
+		return *new(K), *new(T), 0, ErrNotReady
+	}
+	return *new(K), *new(T), 0, ErrEmptyQueue
+}
+
+// weight returns the weight of key.
+// Does not lock m.
+func (m *MultiQueue[K, T]) weight(key K) int {
+	if w, ok := m.weights[key]; ok {
+		return w
+	}
+	return 1
+}
+
+// Len returns the number of elements in all sub-queues.
+func (m *MultiQueue[K, T]) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	n := 0
+	for _, sub := range m.subs {
+		n += sub.Len()
+	}
+	return n
+}
+
+// LenKey returns the number of elements with key.
+func (m *MultiQueue[K, T]) LenKey(key K) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if sub, ok := m.subs[key]; ok {
+		return sub.Len()
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// drainMulti removes all elements of m and returns their contents.
+func drainMulti(t *testing.T, m *MultiQueue[byte, string]) []string {
+	t.Helper()
+	var got []string
+	for m.Len() > 0 {
+		key, c, _, err := m.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != c[0] {
+			t.Errorf("content %q removed for key %q", c, key)
+		}
+		got = append(got, c)
+	}
+	return got
+}
+
+func TestMultiQueueRoundRobin(t *testing.T) {
+	t.Parallel()
+	m, err := NewMultiQueue(func(c string) byte { return c[0] }, Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{"a1", "a2", "a3", "a4", "b1", "c1", "c2"} {
+		if err := m.Insert(NewBaseElement(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.LenKey('a') != 4 || m.LenKey('d') != 0 {
+		t.Errorf("wrong lengths %d, %d", m.LenKey('a'), m.LenKey('d'))
+	}
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		_, c, _, err := m.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, c)
+	}
+	// "d" is served after all keys that already have elements.
+	if err := m.Insert(NewBaseElement("d1")); err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, drainMulti(t, m)...)
+
+	want := []string{"a1", "b1", "c1", "a2", "c2", "d1", "a3", "a4"}
+	if !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if _, _, _, err := m.Remove(); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+}
+
+func TestMultiQueueWeighted(t *testing.T) {
+	t.Parallel()
+	m, err := NewMultiQueue(func(c string) byte { return c[0] }, PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetWeight('a', 0); !errors.Is(err, ErrInvalidWeight) {
+		t.Errorf("expected %v, got %v", ErrInvalidWeight, err)
+	}
+	if err := m.SetWeight('a', 2); err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range []string{"a1", "a2", "a3", "a4", "b1", "b2"} {
+		if err := m.Insert(NewPriorityElement(c, float64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the sub-queues keep their own priority order.
+	want := []string{"a4", "a3", "b2", "a2", "a1", "b1"}
+	if got := drainMulti(t, m); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMultiQueueSkipsUnreadyKeys(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	m, err := NewMultiQueue(func(c string) byte { return c[0] }, Delayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []Element[string]{
+		NewDelayedElement("a1", now.Add(time.Hour)),
+		NewDelayedElement("b1", now),
+	} {
+		if err := m.Insert(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, got, _, err := m.Remove(); err != nil || got != "b1" {
+		t.Errorf("expected b1, got %q, %v", got, err)
+	}
+	if _, _, _, err := m.Remove(); !errors.Is(err, ErrNotReady) {
+		t.Errorf("expected %v, got %v", ErrNotReady, err)
+	}
+}
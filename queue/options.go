@@ -0,0 +1,105 @@
+package queue
+
+// Option configures a queue built with NewQueueWithOptions.
+type Option func(*queueOptions) error
+
+// queueOptions collects the configuration of the Options passed to NewQueueWithOptions.
+type queueOptions struct {
+	capacity int
+	limit    int
+	policy   OverflowPolicy
+	noShrink bool
+	shrink   ShrinkPolicy
+}
+
+// WithInitialCapacity makes the backing slice of the queue start with room for capacity elements.
+// The queue does not shrink it below that either. Fifo queues allocate it with their first
+// insertion. Returns ErrInvalidQueueLimit if capacity < 0.
+func WithInitialCapacity(capacity int) Option {
+	return func(o *queueOptions) error {
+		if capacity < 0 {
+			return ErrInvalidQueueLimit
+		}
+		o.capacity = capacity
+		return nil
+	}
+}
+
+// WithLimit sets the limit of the queue like SetLimit. Returns ErrInvalidQueueLimit if limit < 0.
+func WithLimit(limit int) Option {
+	return func(o *queueOptions) error {
+		if limit < 0 {
+			return ErrInvalidQueueLimit
+		}
+		o.limit = limit
+		return nil
+	}
+}
+
+// WithEvictionPolicy sets what a full FifoLimited queue does on insertion like
+// SetLimitWithPolicy. Returns ErrInvalidOverflowPolicy if policy is unknown.
+func WithEvictionPolicy(policy OverflowPolicy) Option {
+	return func(o *queueOptions) error {
+		if policy < 0 || policy >= numOverflowPolicies {
+			return ErrInvalidOverflowPolicy
+		}
+		o.policy = policy
+		return nil
+	}
+}
+
+// WithNoShrink keeps the queue from reallocating its backing slice to a smaller capacity when
+// elements are removed, for queues whose length oscillates and would otherwise shrink and grow
+// again and again.
+func WithNoShrink() Option {
+	return func(o *queueOptions) error {
+		o.noShrink = true
+		return nil
+	}
+}
+
+// ShrinkPolicy decides after a removal whether the backing slice of a queue holding length
+// elements with room for capacity elements is reallocated, and to which capacity. Capacities below
+// length or the initial capacity are raised to them. Fifo queues keep as many free slots in front
+// of their elements as they hold, so capacity only counts half of their backing slice.
+type ShrinkPolicy func(length, capacity int) (shrink bool, newCap int)
+
+// WithShrinkPolicy replaces the heuristic that decides when the queue shrinks its backing slice
+// with policy, so that memory-sensitive users control when it is reallocated. WithNoShrink takes
+// precedence. Returns ErrInvalidShrinkPolicy if policy is nil.
+func WithShrinkPolicy(policy ShrinkPolicy) Option {
+	return func(o *queueOptions) error {
+		if policy == nil {
+			return ErrInvalidShrinkPolicy
+		}
+		o.shrink = policy
+		return nil
+	}
+}
+
+// NewQueueWithOptions builds a new Queue with the passed Queuetype like NewQueue, configured by
+// opts in order.
+// Returns ErrInvalidQueueType for nonexistent Queuetypes and Comparator, and the error of the
+// first invalid option.
+func NewQueueWithOptions[T any](tp Queuetype, opts ...Option) (*Queue[T], error) {
+	q, err := NewQueue[T](tp)
+	if err != nil {
+		return nil, err
+	}
+	var o queueOptions
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	q.initialCap = o.capacity
+	if tp != Fifo {
+		q.queueSlice = make([]Element[T], 0, o.capacity)
+	}
+	q.maxnumElements = o.limit
+	q.policy = o.policy
+	q.noShrink = o.noShrink
+	q.shrinkPolicy = o.shrink
+	return q, nil
+}
@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestNewQueueWithOptions(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueueWithOptions[int](FifoLimited, WithLimit(2), WithEvictionPolicy(DropNewest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := drain(t, q), []int{0, 1}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	for _, c := range []struct {
+		tp   Queuetype
+		opt  Option
+		want error
+	}{
+		{Comparator, WithNoShrink(), ErrInvalidQueueType},
+		{Lifo, WithLimit(-1), ErrInvalidQueueLimit},
+		{Lifo, WithInitialCapacity(-1), ErrInvalidQueueLimit},
+		{FifoLimited, WithEvictionPolicy(numOverflowPolicies), ErrInvalidOverflowPolicy},
+		{Lifo, WithShrinkPolicy(nil), ErrInvalidShrinkPolicy},
+	} {
+		if _, err := NewQueueWithOptions[int](c.tp, c.opt); !errors.Is(err, c.want) {
+			t.Errorf("queuetype %v: expected %v, got %v", c.tp, c.want, err)
+		}
+	}
+}
+
+func TestWithInitialCapacity(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh} {
+		q, err := NewQueueWithOptions[int](tp, WithInitialCapacity(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 64; i++ {
+			if err := q.Insert(NewBaseElement(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		wantGrows := uint64(0)
+		if tp == Fifo {
+			wantGrows = 1 // the allocation by the first insertion
+		}
+		if m := q.Metrics(); m.Grows != wantGrows {
+			t.Errorf("queuetype %v: expected %d grows, got %d", tp, wantGrows, m.Grows)
+		}
+		drain(t, q)
+		if q.Capacity() < 64 {
+			t.Errorf("queuetype %v: shrunk below initial capacity to %d", tp, q.Capacity())
+		}
+	}
+}
+
+func TestWithNoShrink(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueueWithOptions[int](Lifo, WithNoShrink())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if err := q.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	capBefore := q.Capacity()
+	drain(t, q)
+	if q.Capacity() != capBefore || q.Metrics().Shrinks != 0 {
+		t.Errorf("expected capacity %d without shrinks, got %d", capBefore, q.Capacity())
+	}
+}
+
+func TestWithShrinkPolicy(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo} {
+		// shrink to the length once less than a quarter of the capacity is used.
+		q, err := NewQueueWithOptions[int](tp, WithShrinkPolicy(func(length, capacity int) (bool, int) {
+			return length < capacity/4, 0
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 1000; i++ {
+			if err := q.Insert(NewBaseElement(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for q.Len() > 10 {
+			if _, _, err := q.Remove(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		shrinks := q.Metrics().Shrinks
+		if shrinks == 0 || shrinks > 4 {
+			t.Errorf("queuetype %v: expected at most 4 shrinks, got %d", tp, shrinks)
+		}
+		if got, want := drain(t, q), 10; len(got) != want {
+			t.Errorf("queuetype %v: expected %d elements, got %d", tp, want, len(got))
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package queue
+
+import (
+	"slices"
+	"sort"
+)
+
+// sequenceOf returns the insertion sequence number of elem or 0 if it can't store one.
+func sequenceOf[T any](elem Element[T]) uint64 {
+	if s, ok := elem.(sequenced); ok {
+		return s.sequence()
+	}
+	return 0
+}
+
+// removalCmp compares a and b by the order in which the queue removes them. It returns a negative
+// number if a is removed before b, a positive number if b is removed before a and 0 if the order
+// can't be told apart.
+// Elements with the same main ordering property are removed oldest first, unless the sub-priority
+// or the tie-breaker of a priority queue tells them apart.
+func (q *Queue[T]) removalCmp(a, b Element[T]) int {
+	seqA, seqB := sequenceOf(a), sequenceOf(b)
+	switch q.order {
+	case PriorityHigh, PriorityLow:
+		if c := priorityCmp(q.order, a.Priority(), b.Priority()); c != 0 {
+			return c
+		}
+		if c := priorityCmp(q.order, subPriorityOf(a), subPriorityOf(b)); c != 0 {
+			return c
+		}
+		if c := q.breakTie(a, b); c != 0 {
+			return c
+		}
+	case Lifo:
+		seqA, seqB = seqB, seqA
+	case Comparator:
+		if c := q.ordering().compare(a, b); c != 0 {
+			return c
+		}
+	case Delayed:
+		if c := readyAtOf(a).Compare(readyAtOf(b)); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case seqA < seqB:
+		return -1
+	case seqA > seqB:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// priorityCmp compares the priorities a and b in the removal order of a PriorityHigh or PriorityLow
+// queue.
+func priorityCmp(order Queuetype, a, b float64) int {
+	switch {
+	case a == b:
+		return 0
+	case (a > b) == (order == PriorityHigh):
+		return -1
+	default:
+		return 1
+	}
+}
+
+// breakTie compares a and b with equal priorities by the tie-breaker of the queue like removalCmp.
+// Returns 0 if there is no tie-breaker or it can't tell a and b apart.
+func (q *Queue[T]) breakTie(a, b Element[T]) int {
+	if q.tieBreak == nil {
+		return 0
+	}
+	switch {
+	case q.tieBreak(a.Content(), b.Content()):
+		return -1
+	case q.tieBreak(b.Content(), a.Content()):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// orderedByPriority reports whether the removal order of q depends on the priorities of its
+// elements, so that changing them requires a reorder.
+// Does not lock q.
+func (q *Queue[T]) orderedByPriority() bool {
+	return q.order == PriorityHigh || q.order == PriorityLow || (q.order == Comparator && q.less != nil)
+}
+
+// rebuildInvariant sorts queueSlice so that it upholds the invariant of the Queuetype again.
+// Elements that can't be told apart keep their relative position.
+// FifoLimited and LRU queues over their limit drop their oldest elements.
+// Does not lock q.
+func (q *Queue[T]) rebuildInvariant() {
+	// the element that is removed first belongs to the end of the slice.
+	slices.SortStableFunc(q.queueSlice, func(a, b Element[T]) int {
+		return q.removalCmp(b, a)
+	})
+
+	q.trimToLimit()
+}
+
+// trimToLimit drops the oldest elements of FifoLimited and LRU queues over their limit. They count
+// as evictions.
+// Does not lock q.
+func (q *Queue[T]) trimToLimit() {
+	if (q.order != FifoLimited && q.order != LRU) || q.maxnumElements == 0 || q.numElements <= q.maxnumElements {
+		return
+	}
+	overflow := q.numElements - q.maxnumElements
+	for i := 0; i < overflow; i++ {
+		// the oldest elements are at the end of the slice.
+		elem, err := q.deleteWithoutMemoryManagement(q.numElements - 1)
+		if err != nil {
+			break
+		}
+		q.left(elem)
+		q.counters.evictions.Add(1)
+	}
+	q.handleShrink()
+}
+
+// insertSorted inserts elem at its position according to removalCmp, found by binary search.
+// Requires queueSlice to uphold the invariant.
+func (q *Queue[T]) insertSorted(elem Element[T]) {
+	// all elements before the insertion point are removed after elem.
+	i := sort.Search(q.numElements, func(i int) bool {
+		return q.removalCmp(elem, q.queueSlice[i]) > 0
+	})
+	q.insertAt(i, elem)
+}
+
+// ordering holds what decides the removal order of a queue, so that it can be used without
+// holding the lock of the queue.
+type ordering[T any] struct {
+	order    Queuetype
+	cmp      func(a, b T) int
+	less     func(a, b Element[T]) bool
+	tieBreak func(a, b T) bool
+}
+
+// ordering returns the current ordering of q.
+// Does not lock q.
+func (q *Queue[T]) ordering() ordering[T] {
+	return ordering[T]{order: q.order, cmp: q.cmp, less: q.less, tieBreak: q.tieBreak}
+}
+
+// compare compares the elements a and b of a Comparator queue by less, or by cmp if there is no
+// less, in removal order.
+func (o ordering[T]) compare(a, b Element[T]) int {
+	if o.less == nil {
+		return o.cmp(a.Content(), b.Content())
+	}
+	switch {
+	case o.less(a, b):
+		return -1
+	case o.less(b, a):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// mainCmp compares the snapshots of two elements by the main ordering property in removal order
+// like removalCmp. The insertion age is not taken into account, so it returns 0 for all elements
+// of Queuetypes without a main ordering property.
+func (o ordering[T]) mainCmp(a, b viewEntry[T]) int {
+	switch o.order {
+	case PriorityHigh, PriorityLow:
+		if c := priorityCmp(o.order, a.priority, b.priority); c != 0 {
+			return c
+		}
+		if c := priorityCmp(o.order, a.subPriority, b.subPriority); c != 0 {
+			return c
+		}
+		if o.tieBreak != nil {
+			switch {
+			case o.tieBreak(a.content, b.content):
+				return -1
+			case o.tieBreak(b.content, a.content):
+				return 1
+			}
+		}
+	case Comparator:
+		if o.less == nil {
+			return o.cmp(a.content, b.content)
+		}
+		return o.compare(NewPriorityElement(a.content, a.priority), NewPriorityElement(b.content, b.priority))
+	case Delayed:
+		return a.readyAt.Compare(b.readyAt)
+	}
+	return 0
+}
@@ -0,0 +1,277 @@
+package queue
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func randomPriorityElements(n int) []Element[int] {
+	r := rand.New(rand.NewSource(42))
+	elems := make([]Element[int], n)
+	for i := range elems {
+		elems[i] = NewPriorityElement(i, float64(r.Intn(n/4+1)))
+	}
+	return elems
+}
+
+func TestAppendAllRebuildInvariant(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh, PriorityLow} {
+		inserted, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appended, _ := NewQueue[int](tp)
+
+		for _, e := range randomPriorityElements(1000) {
+			if err := inserted.Insert(NewPriorityElement(e.Content(), e.Priority())); err != nil {
+				t.Fatal(err)
+			}
+		}
+		appended.AppendAll(randomPriorityElements(1000))
+		appended.RebuildInvariant()
+
+		if appended.Len() != inserted.Len() {
+			t.Fatalf("queuetype %v: expected length %d, got %d", tp, inserted.Len(), appended.Len())
+		}
+		for inserted.Len() > 0 {
+			want, _, _ := inserted.Remove()
+			got, _, err := appended.Remove()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Fatalf("queuetype %v: expected %d, got %d", tp, want, got)
+			}
+		}
+	}
+}
+
+func TestRebuildInvariantFifoLimited(t *testing.T) {
+	t.Parallel()
+	q, _ := NewQueue[int](FifoLimited)
+	if err := q.SetLimit(3); err != nil {
+		t.Fatal(err)
+	}
+	q.AppendAll([]Element[int]{NewBaseElement(0), NewBaseElement(1), NewBaseElement(2), NewBaseElement(3), NewBaseElement(4)})
+	q.RebuildInvariant()
+
+	for _, want := range []int{2, 3, 4} {
+		got, _, err := q.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("expected %d, got %d", want, got)
+		}
+	}
+	if q.Len() != 0 {
+		t.Errorf("expected empty queue, got length %d", q.Len())
+	}
+}
+
+const bulkLoadSize = 100000
+
+func BenchmarkAppendAllRebuildInvariant(b *testing.B) {
+	elems := randomPriorityElements(bulkLoadSize)
+	for i := 0; i < b.N; i++ {
+		q, _ := NewQueue[int](PriorityHigh)
+		q.AppendAll(elems)
+		q.RebuildInvariant()
+	}
+}
+
+func BenchmarkInsertLoop(b *testing.B) {
+	elems := randomPriorityElements(bulkLoadSize)
+	for i := 0; i < b.N; i++ {
+		q, _ := NewQueue[int](PriorityHigh)
+		for _, e := range elems {
+			_ = q.Insert(e)
+		}
+	}
+}
+
+type task struct {
+	deadline int
+	name     string
+}
+
+func TestComparatorQueue(t *testing.T) {
+	t.Parallel()
+	q := NewQueueFunc(func(a, b task) int { return a.deadline - b.deadline })
+	tasks := []task{{3, "a"}, {1, "b"}, {2, "c"}, {1, "d"}, {3, "e"}, {0, "f"}}
+	for i, tk := range tasks {
+		// the priority must not influence the order.
+		if err := q.Insert(NewPriorityElement(tk, float64(-i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []string{"f", "b", "d", "c", "a", "e"} {
+		_, peeked, err := q.PeekElem()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := q.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if peeked != got {
+			t.Errorf("peeked %v, removed %v", peeked, got)
+		}
+		if got.name != want {
+			t.Errorf("expected %s, got %s", want, got.name)
+		}
+	}
+}
+
+func TestNewQueueWithComparator(t *testing.T) {
+	t.Parallel()
+	// earliest deadline first, then highest priority first.
+	q := NewQueueWithComparator(func(a, b Element[task]) bool {
+		if a.Content().deadline != b.Content().deadline {
+			return a.Content().deadline < b.Content().deadline
+		}
+		return a.Priority() > b.Priority()
+	})
+	elems := []Element[task]{
+		NewPriorityElement(task{2, "a"}, 1),
+		NewPriorityElement(task{1, "b"}, 1),
+		NewPriorityElement(task{1, "c"}, 5),
+		NewPriorityElement(task{2, "d"}, 1),
+		NewPriorityElement(task{0, "e"}, 0),
+	}
+	for _, elem := range elems {
+		if err := q.Insert(elem); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// "b" overtakes "c" and the update must be reflected in the order.
+	if n := q.UpdatePriority(1, 9, false); n != 3 {
+		t.Errorf("expected 3 updates, got %d", n)
+	}
+
+	var got []string
+	for _, tk := range drain(t, q) {
+		got = append(got, tk.name)
+	}
+	if want := []string{"e", "b", "c", "a", "d"}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestNewQueueComparator(t *testing.T) {
+	t.Parallel()
+	if _, err := NewQueue[int](Comparator); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+}
+
+func TestSubPriority(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		tp   Queuetype
+		want []string
+	}{
+		{PriorityHigh, []string{"x", "b", "d", "a", "c", "e"}},
+		{PriorityLow, []string{"e", "c", "a", "b", "d", "x"}},
+	} {
+		q, err := NewQueue[string](c.tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range []Element[string]{
+			NewPriorityElement2("a", 1, 1),
+			NewPriorityElement2("b", 1, 3),
+			NewPriorityElement("c", 1), // sub-priority 0
+			NewPriorityElement2("d", 1, 3),
+			NewPriorityElement2("e", 1, -1),
+			NewPriorityElement2("x", 2, -5),
+		} {
+			if err := q.Insert(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := drain(t, q); !equalContents(got, c.want) {
+			t.Errorf("queuetype %v: expected %v, got %v", c.tp, c.want, got)
+		}
+	}
+}
+
+func TestConvertTo(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		tp   Queuetype
+		want []string
+	}{
+		{PriorityHigh, []string{"b", "d", "e", "a", "c"}},
+		{Lifo, []string{"e", "d", "c", "b", "a"}},
+		{FifoLimited, []string{"c", "d", "e"}},
+	} {
+		q, err := NewQueue[string](Fifo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := q.SetLimit(3); err != nil {
+			t.Fatal(err)
+		}
+		for i, content := range []string{"a", "b", "c", "d"} {
+			if err := q.Insert(NewPriorityElement(content, float64(i%2))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := q.ConvertTo(c.tp); err != nil {
+			t.Fatal(err)
+		}
+		// insertions after the conversion follow the new order.
+		if err := q.Insert(NewPriorityElement("e", 0.5)); err != nil {
+			t.Fatal(err)
+		}
+		if got := drain(t, q); !equalContents(got, c.want) {
+			t.Errorf("queuetype %v: expected %v, got %v", c.tp, c.want, got)
+		}
+	}
+
+	q := NewQueueFunc(strings.Compare)
+	if err := q.ConvertTo(Comparator); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		tp   Queuetype
+		want []string
+	}{
+		{Fifo, []string{"e", "d", "c", "b", "a"}},
+		{Lifo, []string{"a", "b", "c", "d", "e"}},
+		// ties stay oldest first.
+		{PriorityHigh, []string{"a", "c", "e", "b", "d"}},
+		{PriorityLow, []string{"b", "d", "a", "c", "e"}},
+	} {
+		q, err := NewQueue[string](c.tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, content := range []string{"a", "b", "c", "d", "e"} {
+			if err := q.Insert(NewPriorityElement(content, float64(i%2))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := q.Reverse(); err != nil {
+			t.Fatal(err)
+		}
+		if got := drain(t, q); !equalContents(got, c.want) {
+			t.Errorf("queuetype %v: expected %v, got %v", c.tp, c.want, got)
+		}
+	}
+
+	q, _ := NewQueue[string](LRU)
+	if err := q.Reverse(); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+}
@@ -1,10 +1,21 @@
 package queue
 
+import "sort"
+
 // PeekElem returns a copy of the elem that would be returned on a call to Remove().
+// Expired elements at the head are dropped like in Remove.
 // Returns an error of type ErrEmptyQueue when the list is empty.
 func (q *Queue[T]) PeekElem() (float64, T, error) {
-	q.lock.Lock()
-	defer q.lock.Unlock()
+	q.lock.RLock()
+	if q.headExpired() {
+		// dropping the expired heads needs the write lock.
+		q.lock.RUnlock()
+		q.lock.Lock()
+		defer q.lock.Unlock()
+		q.dropExpiredHead()
+	} else {
+		defer q.lock.RUnlock()
+	}
 
 	if q.numElements == 0 {
 		return 0, *new(T), ErrEmptyQueue
@@ -13,22 +24,126 @@ func (q *Queue[T]) PeekElem() (float64, T, error) {
 	return elem.Priority(), elem.Content(), nil
 }
 
+// PeekLast returns a copy of the elem that would be removed last, the lowest priority of a
+// PriorityHigh and the highest of a PriorityLow queue, like PeekElemAtIndex(Len()-1).
+// Returns an error of type ErrEmptyQueue when the list is empty.
+func (q *Queue[T]) PeekLast() (float64, T, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if q.numElements == 0 {
+		return 0, *new(T), ErrEmptyQueue
+	}
+	elem := q.queueSlice[0] // dereference is a copy
+	return elem.Priority(), elem.Content(), nil
+}
+
 // PeekElemAtIndex returns a copy of the elem at index.
 // Returns an error of type ErrEmptyQueue when the list is empty.
-// Returns an error of type ErrIndexOutOfBounds when the provided index is out of bounds.
+// Returns an *IndexError, which matches ErrIndexOutOfBounds, when the provided index is out of
+// bounds.
 func (q *Queue[T]) PeekElemAtIndex(index int) (float64, T, error) {
-	q.lock.Lock()
-	defer q.lock.Unlock()
+	q.lock.RLock()
+	defer q.lock.RUnlock()
 
 	if q.numElements == 0 {
 		return 0, *new(T), ErrEmptyQueue
 	}
 
-	realIndex := (q.numElements - 1) - index
-	if realIndex < 0 {
-		return 0, *new(T), ErrIndexOutOfBounds
+	if index < 0 || index >= q.numElements {
+		return 0, *new(T), &IndexError{Index: index, Len: q.numElements}
 	}
+	realIndex := (q.numElements - 1) - index
 
 	elem := q.queueSlice[realIndex] // dereference is a copy
 	return elem.Priority(), elem.Content(), nil
 }
+
+// PeekElemWithSeq returns a copy of the elem at index together with its insertion sequence number.
+// The sequence number is assigned on Insert or Append, increases monotonically with every insertion
+// and is kept when the queue reorders the element, e.g. on ElementHandle.SetPriority. UpdatePriority
+// assigns new ones, since it places the elements behind those that already have the new priority.
+// Elements that cannot store a sequence number report 0.
+// Returns an error of type ErrEmptyQueue when the list is empty.
+// Returns an *IndexError, which matches ErrIndexOutOfBounds, when the provided index is out of
+// bounds.
+func (q *Queue[T]) PeekElemWithSeq(index int) (uint64, float64, T, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if q.numElements == 0 {
+		return 0, 0, *new(T), ErrEmptyQueue
+	}
+
+	if index < 0 || index >= q.numElements {
+		return 0, 0, *new(T), &IndexError{Index: index, Len: q.numElements}
+	}
+	realIndex := (q.numElements - 1) - index
+
+	elem := q.queueSlice[realIndex]
+	var seq uint64
+	if s, ok := elem.(sequenced); ok {
+		seq = s.sequence()
+	}
+	return seq, elem.Priority(), elem.Content(), nil
+}
+
+// Contains reports whether the queue holds an element whose content matches pred, in O(n).
+func (q *Queue[T]) Contains(pred func(T) bool) bool {
+	_, _, ok := q.Find(pred)
+	return ok
+}
+
+// Find returns the content and priority of the first element in removal order whose content
+// matches pred, in O(n), and whether there is one.
+func (q *Queue[T]) Find(pred func(T) bool) (T, float64, bool) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	for i := q.numElements - 1; i >= 0; i-- {
+		elem := q.queueSlice[i]
+		if pred(elem.Content()) {
+			return elem.Content(), elem.Priority(), true
+		}
+	}
+	return *new(T), 0, false
+}
+
+// SearchPriority binary searches a PriorityHigh or PriorityLow queue for an element with priority
+// target in O(log n). Returns the index in removal order (as used by PeekElemAtIndex) of the first
+// such element that would be removed and whether one was found.
+// For other Queuetypes the queue is not sorted by priority, so (-1, false) is returned.
+func (q *Queue[T]) SearchPriority(target float64) (int, bool) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	lo, hi := q.priorityBlock(target)
+	if lo == hi {
+		return -1, false
+	}
+	// the element removed first is at the end of the block.
+	return q.numElements - hi, true
+}
+
+// priorityBlock returns the bounds [lo, hi) of the contiguous block of queueSlice holding the
+// elements with priority target. lo == hi if there is none or the queue isn't sorted by priority.
+// Does not lock q.
+func (q *Queue[T]) priorityBlock(target float64) (int, int) {
+	var before, within func(p float64) bool
+	switch q.order {
+	case PriorityHigh:
+		// ascending priorities towards the end of the slice.
+		before = func(p float64) bool { return p < target }
+		within = func(p float64) bool { return p <= target }
+	case PriorityLow:
+		// descending priorities towards the end of the slice.
+		before = func(p float64) bool { return p > target }
+		within = func(p float64) bool { return p >= target }
+	default:
+		return 0, 0
+	}
+
+	lo := sort.Search(q.numElements, func(i int) bool { return !before(q.queueSlice[i].Priority()) })
+	hi := sort.Search(q.numElements, func(i int) bool { return !within(q.queueSlice[i].Priority()) })
+	return lo, hi
+}
@@ -0,0 +1,269 @@
+package queue
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestPeekElemWithSeq(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priorities := []float64{2, 5, 2, 1, 5, 3}
+	for i, p := range priorities {
+		if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seqs := make(map[int]uint64)
+	for j := 0; j < q.Len(); j++ {
+		seq, _, content, err := q.PeekElemWithSeq(j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seqs[content] = seq
+	}
+	// content i was the i-th insertion.
+	for i := 1; i < len(priorities); i++ {
+		if seqs[i] <= seqs[i-1] {
+			t.Errorf("expected sequence number of element %d to be > %d, got %d", i, seqs[i-1], seqs[i])
+		}
+	}
+
+	if n := q.UpdatePriority(2, 10, false); n != 2 {
+		t.Errorf("expected 2 updates, got %d", n)
+	}
+
+	// the updated elements are reinserted in insertion order after all others.
+	last := seqs[len(priorities)-1]
+	for j := 0; j < q.Len(); j++ {
+		seq, _, content, err := q.PeekElemWithSeq(j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := seqs[content]
+		switch content {
+		case 0:
+			want = last + 1
+		case 2:
+			want = last + 2
+		}
+		if want != seq {
+			t.Errorf("expected sequence number %d for element %d, got %d", want, content, seq)
+		}
+	}
+
+	// the updated elements are removed first and in insertion order.
+	for _, want := range []int{0, 2, 1, 4, 5, 3} {
+		got, _, err := q.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("expected %d, got %d", want, got)
+		}
+	}
+
+	if _, _, _, err := q.PeekElemWithSeq(0); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+}
+
+func TestUpdatePriorityPerformanceFlag(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range []float64{2, 5, 2, 1, 2} {
+			if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		newPriority := 10.0
+		if tp == PriorityLow {
+			newPriority = -10
+		}
+		if n := q.UpdatePriority(2, newPriority, true); n != 3 {
+			t.Errorf("queuetype %v: expected 3 updates, got %d", tp, n)
+		}
+
+		// the updated elements are removed first, youngest first.
+		want := []int{4, 2, 0, 1, 3}
+		if tp == PriorityLow {
+			want = []int{4, 2, 0, 3, 1}
+		}
+		for _, w := range want {
+			seq, _, _, err := q.PeekElemWithSeq(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := q.Remove()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != w {
+				t.Errorf("queuetype %v: expected %d, got %d", tp, w, got)
+			}
+			// the updated elements are restamped youngest first.
+			want := map[int]uint64{0: 8, 1: 2, 2: 7, 3: 4, 4: 6}[got]
+			if seq != want {
+				t.Errorf("queuetype %v: expected sequence number %d for %d, got %d", tp, want, got, seq)
+			}
+		}
+	}
+}
+
+func TestInsertInvalidQueuetypeKeepsSequence(t *testing.T) {
+	t.Parallel()
+	q := &Queue[int]{order: numQueuetypes}
+	if err := q.Insert(NewBaseElement(1)); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+	if q.seq != 0 {
+		t.Errorf("expected no sequence number to be used, got %d", q.seq)
+	}
+}
+
+func TestSearchPriority(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range []float64{5, 1, 3, 3, 7, 3, 1} {
+			if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, target := range []float64{1, 3, 5, 7} {
+			index, found := q.SearchPriority(target)
+			if !found {
+				t.Errorf("queuetype %v: expected to find priority %v", tp, target)
+				continue
+			}
+			// the first match in removal order is the oldest element with that priority.
+			for j := 0; j < q.Len(); j++ {
+				p, _, err := q.PeekElemAtIndex(j)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if p == target {
+					if index != j {
+						t.Errorf("queuetype %v, priority %v: expected index %d, got %d", tp, target, j, index)
+					}
+					break
+				}
+			}
+			_, c, _ := q.PeekElemAtIndex(index)
+			if want := map[float64]int{1: 1, 3: 2, 5: 0, 7: 4}[target]; c != want {
+				t.Errorf("queuetype %v, priority %v: expected element %d, got %d", tp, target, want, c)
+			}
+		}
+
+		for _, target := range []float64{0, 2, 4, 8} {
+			if index, found := q.SearchPriority(target); found || index != -1 {
+				t.Errorf("queuetype %v: expected priority %v not to be found, got %d", tp, target, index)
+			}
+		}
+	}
+
+	fifo, _ := NewQueue[int](Fifo)
+	_ = fifo.Insert(NewPriorityElement(0, 1))
+	if _, found := fifo.SearchPriority(1); found {
+		t.Error("expected no search on Fifo queue")
+	}
+}
+
+func TestPeekElemAtIndexError(t *testing.T) {
+	t.Parallel()
+	q := fifoOf(t, 1, 2, 3)
+	for _, index := range []int{-1, 3, 10} {
+		_, _, err := q.PeekElemAtIndex(index)
+		if !errors.Is(err, ErrIndexOutOfBounds) {
+			t.Errorf("index %d: expected %v, got %v", index, ErrIndexOutOfBounds, err)
+		}
+
+		var indexErr *IndexError
+		if !errors.As(err, &indexErr) {
+			t.Fatalf("index %d: expected an *IndexError, got %T", index, err)
+		}
+		if indexErr.Index != index || indexErr.Len != 3 {
+			t.Errorf("index %d: expected index %d and length 3, got %+v", index, index, indexErr)
+		}
+		want := fmt.Sprintf("index %d, length 3", index)
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("index %d: expected %q in %q", index, want, err.Error())
+		}
+
+		if _, _, _, err := q.PeekElemWithSeq(index); !errors.As(err, &indexErr) {
+			t.Errorf("index %d: expected an *IndexError from PeekElemWithSeq, got %v", index, err)
+		}
+	}
+}
+
+func TestPeeksShareReadLock(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Insert(NewPriorityElement(1, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a reader holding the lock must not block other readers.
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = q.PeekElem()
+		_, _, _ = q.PeekElemAtIndex(0)
+		_ = q.Len()
+		_ = q.GetAllElements()
+		for range q.All() {
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("peeks blocked on a read lock")
+	}
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[string](PriorityLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range []string{"apple", "avocado", "banana", "apricot"} {
+		if err := q.Insert(NewPriorityElement(c, float64(4-i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first match in removal order.
+	c, p, ok := q.Find(func(c string) bool { return strings.HasPrefix(c, "a") })
+	if !ok || c != "apricot" || p != 1 {
+		t.Errorf("expected apricot with priority 1, got %q, %v, %v", c, p, ok)
+	}
+	if q.Contains(func(c string) bool { return c == "cherry" }) {
+		t.Error("found cherry")
+	}
+	if !q.Contains(func(c string) bool { return c == "banana" }) {
+		t.Error("missed banana")
+	}
+}
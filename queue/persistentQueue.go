@@ -0,0 +1,325 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// walMagic starts every write-ahead log of a PersistentQueue, followed by the format version and
+// the Queuetype.
+var walMagic = [4]byte{'D', 'A', 'Q', 'W'}
+
+const walVersion = 1
+
+const (
+	// walInsertBase records the insertion of a BaseElement.
+	walInsertBase byte = iota
+	// walInsertPriority records the insertion of a PriorityElement.
+	walInsertPriority
+	// walRemove records the removal of the head of the queue.
+	walRemove
+)
+
+// PersistentQueue is a Queue backed by a write-ahead log file, so that it survives crashes and
+// restarts. Every Insert and Remove is appended to the log and synced to disk before it takes
+// effect, and Open recovers the queue by replaying the log.
+// The contents are encoded with a ContentCodec. Comparator queues are not supported, since their
+// comparator can't be persisted, and neither are Delayed queues and elements that expire or become
+// ready at a point in time: the log only records priorities, and removals that depend on the time
+// could be logged without taking effect.
+type PersistentQueue[T any] struct {
+	// lock serializes the mutations, so that the log records them in the order they happen.
+	lock  sync.Mutex
+	q     *Queue[T]
+	codec ContentCodec[T]
+	path  string
+	f     *os.File
+}
+
+// Open opens the persistent queue of Queuetype tp logged at path, creating the log if it does not
+// exist, and recovers its elements. Since the elements are inserted and removed again in the same
+// order, the recovered queue has the same removal order as before.
+// A damaged record at the end of the log, as left behind by a crash during a write, is discarded
+// together with everything after it, so the operation it recorded has not taken effect.
+// Returns ErrInvalidQueueType if tp is Comparator or Delayed or differs from the Queuetype of the
+// log and an error of type ErrCorruptData if the log was not written by a PersistentQueue.
+func Open[T any](path string, tp Queuetype, codec ContentCodec[T]) (*PersistentQueue[T], error) {
+	if tp == Comparator || tp == Delayed {
+		return nil, ErrInvalidQueueType
+	}
+	q, err := NewQueue[T](tp)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening log")
+	}
+	p := &PersistentQueue[T]{q: q, codec: codec, path: path, f: f}
+	if err := p.recover(tp); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// recover replays the log into p.q and positions the file at the end of the last intact record.
+// An empty log gets a header for tp.
+func (p *PersistentQueue[T]) recover(tp Queuetype) error {
+	data, err := io.ReadAll(p.f)
+	if err != nil {
+		return errors.Wrap(err, "reading log")
+	}
+	if len(data) == 0 {
+		header := walHeader(tp)
+		if _, err := p.f.Write(header); err != nil {
+			return errors.Wrap(err, "writing log header")
+		}
+		return errors.Wrap(p.f.Sync(), "syncing log")
+	}
+
+	end, err := p.replay(data, tp)
+	if err != nil {
+		return err
+	}
+	if err := p.f.Truncate(int64(end)); err != nil {
+		return errors.Wrap(err, "discarding damaged log records")
+	}
+	_, err = p.f.Seek(int64(end), io.SeekStart)
+	return errors.Wrap(err, "seeking end of log")
+}
+
+// walHeader returns the header of a log for a queue of Queuetype tp.
+func walHeader(tp Queuetype) []byte {
+	header := append(append([]byte(nil), walMagic[:]...), walVersion)
+	return binary.AppendUvarint(header, uint64(tp))
+}
+
+// replay applies the records in data to p.q and returns the offset behind the last intact record.
+func (p *PersistentQueue[T]) replay(data []byte, tp Queuetype) (int, error) {
+	if len(data) < len(walMagic)+1 || !bytes.Equal(data[:len(walMagic)], walMagic[:]) {
+		return 0, errors.Wrap(ErrCorruptData, "unknown log format")
+	}
+	if data[len(walMagic)] != walVersion {
+		return 0, errors.Wrapf(ErrCorruptData, "unknown log version %d", data[len(walMagic)])
+	}
+	order, n := binary.Uvarint(data[len(walMagic)+1:])
+	if n <= 0 {
+		return 0, errors.Wrap(ErrCorruptData, "reading log header")
+	}
+	if Queuetype(order) != tp {
+		return 0, errors.Wrapf(ErrInvalidQueueType, "log holds a queue of Queuetype %d", order)
+	}
+
+	off := len(walMagic) + 1 + n
+	for off < len(data) {
+		size, ok := walRecordSize(data[off:])
+		if !ok {
+			break
+		}
+		record := data[off : off+size-4]
+		if crc32.ChecksumIEEE(record) != binary.LittleEndian.Uint32(data[off+size-4:]) {
+			break
+		}
+		if err := p.apply(record); err != nil {
+			return 0, errors.Wrapf(err, "replaying log record at offset %d", off)
+		}
+		off += size
+	}
+	return off, nil
+}
+
+// walRecordSize returns the size of the record at the start of data including its checksum.
+// Reports false if data does not hold a complete record.
+func walRecordSize(data []byte) (int, bool) {
+	if len(data) == 0 {
+		return 0, false
+	}
+	size := 1
+	if data[0] != walRemove {
+		if len(data) < 1+8 {
+			return 0, false
+		}
+		l, n := binary.Uvarint(data[1+8:])
+		if n <= 0 || l > uint64(len(data)) {
+			return 0, false
+		}
+		size += 8 + n + int(l)
+	}
+	size += 4
+	if size > len(data) {
+		return 0, false
+	}
+	return size, true
+}
+
+// apply applies a record without its checksum to p.q.
+func (p *PersistentQueue[T]) apply(record []byte) error {
+	kind := record[0]
+	if kind == walRemove {
+		_, _, err := p.q.Remove()
+		return err
+	}
+	if kind != walInsertBase && kind != walInsertPriority {
+		return errors.Wrapf(ErrCorruptData, "unknown record kind %d", kind)
+	}
+
+	priority := math.Float64frombits(binary.LittleEndian.Uint64(record[1:9]))
+	_, n := binary.Uvarint(record[9:])
+	c, err := p.codec.Decode(record[9+n:])
+	if err != nil {
+		return errors.Wrap(err, "decoding content")
+	}
+	if kind == walInsertBase {
+		return p.q.Insert(NewBaseElement(c))
+	}
+	return p.q.Insert(NewPriorityElement(c, priority))
+}
+
+// appendRecord appends a record with its checksum to buf.
+func appendRecord(buf []byte, kind byte, priority float64, content []byte) []byte {
+	start := len(buf)
+	buf = append(buf, kind)
+	if kind != walRemove {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(priority))
+		buf = binary.AppendUvarint(buf, uint64(len(content)))
+		buf = append(buf, content...)
+	}
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf[start:]))
+}
+
+// writeRecord appends a record to the log and syncs it to disk. A partially written record is cut
+// off again, so that it can't hide the records that follow it from recovery.
+// Does not lock p.
+func (p *PersistentQueue[T]) writeRecord(record []byte) error {
+	end, err := p.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrap(err, "locating end of log")
+	}
+	if _, err := p.f.Write(record); err != nil {
+		if p.f.Truncate(end) == nil {
+			_, _ = p.f.Seek(end, io.SeekStart)
+		}
+		return errors.Wrap(err, "writing log record")
+	}
+	return errors.Wrap(p.f.Sync(), "syncing log")
+}
+
+// Insert logs the insertion of elem and inserts it like Queue.Insert. Elements that are neither
+// BaseElements nor PriorityElements are recovered as PriorityElements.
+// If the record can't be written, elem is not inserted.
+// Returns ErrUnsupportedElement for elements that expire or become ready at a point in time, like
+// ExpiringElement and DelayedElement.
+func (p *PersistentQueue[T]) Insert(elem Element[T]) error {
+	if _, ok := elem.(expiring); ok {
+		return errors.Wrap(ErrUnsupportedElement, "persisting an expiring element")
+	}
+	if _, ok := elem.(delayed); ok {
+		return errors.Wrap(ErrUnsupportedElement, "persisting a delayed element")
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	data, err := p.codec.Encode(elem.Content())
+	if err != nil {
+		return errors.Wrap(err, "encoding content")
+	}
+	kind := walInsertPriority
+	if _, ok := elem.(*BaseElement[T]); ok {
+		kind = walInsertBase
+	}
+	if err := p.writeRecord(appendRecord(nil, kind, elem.Priority(), data)); err != nil {
+		return err
+	}
+	return p.q.Insert(elem)
+}
+
+// Remove logs the removal and pops the element that is meant to be removed first like
+// Queue.Remove. If the record can't be written, nothing is removed.
+// If the queue is empty, an error is returned.
+func (p *PersistentQueue[T]) Remove() (T, float64, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.q.Len() == 0 {
+		return *new(T), 0, ErrEmptyQueue
+	}
+	if err := p.writeRecord(appendRecord(nil, walRemove, 0, nil)); err != nil {
+		return *new(T), 0, err
+	}
+	return p.q.Remove()
+}
+
+// PeekElem returns the priority and content of the element that is removed next like
+// Queue.PeekElem.
+func (p *PersistentQueue[T]) PeekElem() (float64, T, error) {
+	return p.q.PeekElem()
+}
+
+// Len returns the number of elements in the queue.
+func (p *PersistentQueue[T]) Len() int {
+	return p.q.Len()
+}
+
+// Compact rewrites the log so that it only records the insertions of the current elements, which
+// bounds its size by the size of the queue. Like SaveToFile it writes a temporary file first and
+// renames it to the log, so a crash leaves either the old or the new log behind.
+func (p *PersistentQueue[T]) Compact() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.q.lock.Lock()
+	buf := walHeader(p.q.order)
+	for _, elem := range p.q.insertionOrder() {
+		data, err := p.codec.Encode(elem.Content())
+		if err != nil {
+			p.q.lock.Unlock()
+			return errors.Wrap(err, "encoding content")
+		}
+		kind := walInsertPriority
+		if _, ok := elem.(*BaseElement[T]); ok {
+			kind = walInsertBase
+		}
+		buf = appendRecord(buf, kind, elem.Priority(), data)
+	}
+	p.q.lock.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), filepath.Base(p.path)+".tmp*")
+	if err != nil {
+		return errors.Wrap(err, "creating temporary log file")
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "writing compacted log")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "syncing compacted log")
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "replacing log")
+	}
+	p.f.Close()
+	p.f = tmp
+	_, err = p.f.Seek(0, io.SeekEnd)
+	return errors.Wrap(err, "seeking end of log")
+}
+
+// Close closes the log. The queue must not be used afterwards.
+func (p *PersistentQueue[T]) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return errors.Wrap(p.f.Close(), "closing log")
+}
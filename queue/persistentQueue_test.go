@@ -0,0 +1,178 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func openPersistent(t *testing.T, path string, tp Queuetype) *PersistentQueue[int] {
+	t.Helper()
+	p, err := Open[int](path, tp, intCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func drainPersistent(t *testing.T, p *PersistentQueue[int]) []int {
+	t.Helper()
+	var ret []int
+	for p.Len() > 0 {
+		c, _, err := p.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+func TestPersistentQueueRecover(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "queue.wal")
+	p := openPersistent(t, path, PriorityHigh)
+	for i := 0; i < 8; i++ {
+		if err := p.Insert(NewPriorityElement(i, float64(i%3))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Insert(NewBaseElement(100)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, _, err := p.Remove(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered := openPersistent(t, path, PriorityHigh)
+	defer recovered.Close()
+	// 2 5 | 1 4 7 | 0 3 6 100 with the first three removed.
+	want := []int{4, 7, 0, 3, 6, 100}
+	if got := drainPersistent(t, recovered); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if _, _, err := recovered.Remove(); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+}
+
+func TestPersistentQueueTornRecord(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "queue.wal")
+	p := openPersistent(t, path, Fifo)
+	for i := 0; i < 3; i++ {
+		if err := p.Insert(NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Close()
+
+	// a crash in the middle of the last write leaves a partial record behind.
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-2); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered := openPersistent(t, path, Fifo)
+	if err := recovered.Insert(NewBaseElement(3)); err != nil {
+		t.Fatal(err)
+	}
+	recovered.Close()
+
+	again := openPersistent(t, path, Fifo)
+	defer again.Close()
+	if got, want := drainPersistent(t, again), []int{0, 1, 3}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPersistentQueueCompact(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "queue.wal")
+	p := openPersistent(t, path, Lifo)
+	for i := 0; i < 100; i++ {
+		_ = p.Insert(NewBaseElement(i))
+	}
+	for i := 0; i < 97; i++ {
+		_, _, _ = p.Remove()
+	}
+	before, _ := os.Stat(path)
+	if err := p.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size() {
+		t.Errorf("expected the log to shrink from %d bytes, got %d", before.Size(), after.Size())
+	}
+	// the log keeps working after compaction.
+	_ = p.Insert(NewBaseElement(3))
+	p.Close()
+
+	recovered := openPersistent(t, path, Lifo)
+	defer recovered.Close()
+	if got, want := drainPersistent(t, recovered), []int{3, 2, 1, 0}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPersistentQueueOpenErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "queue.wal")
+	p := openPersistent(t, path, Fifo)
+	p.Close()
+
+	if _, err := Open[int](path, Lifo, intCodec{}); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+	if _, err := Open[int](path, Comparator, intCodec{}); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+	if _, err := Open[int](filepath.Join(dir, "delayed.wal"), Delayed, intCodec{}); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+
+	garbage := filepath.Join(dir, "garbage")
+	if err := os.WriteFile(garbage, []byte("not a log"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open[int](garbage, Fifo, intCodec{}); !errors.Is(err, ErrCorruptData) {
+		t.Errorf("expected %v, got %v", ErrCorruptData, err)
+	}
+}
+
+func TestPersistentQueueTimedElements(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "queue.wal")
+	p := openPersistent(t, path, PriorityHigh)
+	for _, elem := range []Element[int]{
+		NewPriorityElementWithTTL(1, 1, time.Hour),
+		NewDelayedElement(2, time.Now().Add(time.Hour)),
+	} {
+		if err := p.Insert(elem); !errors.Is(err, ErrUnsupportedElement) {
+			t.Errorf("%T: expected %v, got %v", elem, ErrUnsupportedElement, err)
+		}
+	}
+	if err := p.Insert(NewPriorityElement(3, 1)); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	// the rejected elements were not logged.
+	p = openPersistent(t, path, PriorityHigh)
+	defer p.Close()
+	if got, want := drainPersistent(t, p), []int{3}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
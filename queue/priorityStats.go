@@ -0,0 +1,84 @@
+package queue
+
+import "sort"
+
+// PriorityHistogram counts the elements of the queue by priority, for example to decide which
+// priorities to shed under load. buckets are the ascending upper bounds of the buckets: the i-th
+// count holds the elements with buckets[i-1] <= priority < buckets[i], the first count those below
+// buckets[0] and the last of the len(buckets)+1 counts those at or above the last bound.
+// Locks q. Returns ErrInvalidBuckets if buckets are not strictly ascending.
+func (q *Queue[T]) PriorityHistogram(buckets []float64) ([]int, error) {
+	for i := 1; i < len(buckets); i++ {
+		if !(buckets[i-1] < buckets[i]) {
+			return nil, ErrInvalidBuckets
+		}
+	}
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	counts := make([]int, len(buckets)+1)
+	for _, elem := range q.queueSlice {
+		p := elem.Priority()
+		counts[sort.Search(len(buckets), func(i int) bool { return p < buckets[i] })]++
+	}
+	return counts, nil
+}
+
+// MinPriority returns the lowest priority of the elements in the queue, in O(1) for PriorityHigh
+// and PriorityLow queues and in O(n) otherwise.
+// Locks q. Returns ErrEmptyQueue if the queue is empty.
+func (q *Queue[T]) MinPriority() (float64, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return q.priorityBound(PriorityLow)
+}
+
+// MaxPriority returns the highest priority of the elements in the queue like MinPriority.
+// Locks q. Returns ErrEmptyQueue if the queue is empty.
+func (q *Queue[T]) MaxPriority() (float64, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return q.priorityBound(PriorityHigh)
+}
+
+// MeanPriority returns the mean of the priorities of the elements in the queue in O(n).
+// Locks q. Returns ErrEmptyQueue if the queue is empty.
+func (q *Queue[T]) MeanPriority() (float64, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if q.numElements == 0 {
+		return 0, ErrEmptyQueue
+	}
+	var sum float64
+	for _, elem := range q.queueSlice {
+		sum += elem.Priority()
+	}
+	return sum / float64(q.numElements), nil
+}
+
+// priorityBound returns the priority a queue of Queuetype order removes first, that is the highest
+// priority for PriorityHigh and the lowest for PriorityLow.
+// Does not lock q.
+func (q *Queue[T]) priorityBound(order Queuetype) (float64, error) {
+	if q.numElements == 0 {
+		return 0, ErrEmptyQueue
+	}
+	switch {
+	case q.order == order:
+		// the head is removed first.
+		return q.queueSlice[q.numElements-1].Priority(), nil
+	case q.order == PriorityHigh || q.order == PriorityLow:
+		return q.queueSlice[0].Priority(), nil
+	}
+
+	bound := q.queueSlice[0].Priority()
+	for _, elem := range q.queueSlice[1:] {
+		if p := elem.Priority(); priorityCmp(order, p, bound) < 0 {
+			bound = p
+		}
+	}
+	return bound, nil
+}
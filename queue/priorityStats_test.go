@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestPriorityHistogram(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range []float64{-1, 0, 2, 5, 5, 9, 10, 12} {
+		if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	counts, err := q.PriorityHistogram([]float64{0, 5, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3, 2}; !equalContents(counts, want) {
+		t.Errorf("expected %v, got %v", want, counts)
+	}
+	if counts, _ := q.PriorityHistogram(nil); !equalContents(counts, []int{8}) {
+		t.Errorf("expected a single bucket with all elements, got %v", counts)
+	}
+	if _, err := q.PriorityHistogram([]float64{1, 1}); !errors.Is(err, ErrInvalidBuckets) {
+		t.Errorf("expected %v, got %v", ErrInvalidBuckets, err)
+	}
+}
+
+func TestPriorityBounds(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh, PriorityLow} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := q.MinPriority(); !errors.Is(err, ErrEmptyQueue) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrEmptyQueue, err)
+		}
+		if _, err := q.MeanPriority(); !errors.Is(err, ErrEmptyQueue) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrEmptyQueue, err)
+		}
+		for i, p := range []float64{3, -2, 7, 0} {
+			if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		minP, _ := q.MinPriority()
+		maxP, _ := q.MaxPriority()
+		mean, _ := q.MeanPriority()
+		if minP != -2 || maxP != 7 || mean != 2 {
+			t.Errorf("queuetype %v: expected (-2, 7, 2), got (%v, %v, %v)", tp, minP, maxP, mean)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package queue
 
 import (
-	"sync"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 // Queuetype is the enum type for queue invariants.
@@ -19,6 +23,12 @@ import (
 //		len(queueSlice)-1 is the elem with highest priority
 //	PriorityLow:
 //		len(queueSlice)-1 is the elem with lowest priority
+//	Comparator:
+//		len(queueSlice)-1 is the smallest elem according to the comparator of the queue
+//	LRU:
+//		len(queueSlice)-1 is the least recently inserted or touched elem
+//	Delayed:
+//		len(queueSlice)-1 is the elem that is ready first
 type Queuetype int
 
 const (
@@ -37,7 +47,44 @@ const (
 	// FifoLimited means that the queue has a maximum capacity. Requires extra call to set capacity.
 	FifoLimited
 
-	numQueuetypes = 5
+	// Comparator means that on remove the elem with the smallest content according to the
+	// comparator of the queue is returned. Requires NewQueueFunc or NewQueueWithComparator.
+	Comparator
+
+	// LRU means that the queue has a maximum capacity like FifoLimited, but Touch moves an element
+	// back to the most recently used position. On overflow and on remove the least recently used
+	// elem is returned. Requires extra call to set capacity.
+	LRU
+
+	// Delayed means that on remove the elem that is ready first is returned, but only once it is
+	// ready. Elements are ready at the time given to NewDelayedElement, other elements are ready
+	// immediately. Readiness is measured with the clock of the queue, see SetClock.
+	// Drain and DrainFilter remove elements regardless of their readiness. The encodings of the
+	// queue do not keep the ready times.
+	Delayed
+
+	numQueuetypes = 8
+)
+
+// OverflowPolicy determines what Insert does when a FifoLimited queue is at its limit.
+type OverflowPolicy int
+
+const (
+	// DropOldest evicts the oldest element to make room for the new one. It is the default policy.
+	DropOldest OverflowPolicy = iota
+
+	// DropNewest drops the new element and keeps the queue as it is.
+	DropNewest
+
+	// RejectWithError makes Insert fail with ErrQueueFull.
+	RejectWithError
+
+	// EvictLowestPriority evicts the element with the lowest priority, the oldest of them if there
+	// are several. If the new element has a lower priority than all elements in the queue, the new
+	// element is dropped instead.
+	EvictLowestPriority
+
+	numOverflowPolicies = 4
 )
 
 // Element is the interface encapsulating all element types
@@ -52,17 +99,78 @@ type Element[T any] interface {
 // Queue is a queue of type Queuetype
 type Queue[T any] struct {
 	order          Queuetype
-	lock           sync.Mutex
+	lock           queueLock
 	queueSlice     []Element[T]
 	numElements    int
 	maxnumElements int
+
+	// policy is applied by FifoLimited queues at their limit. See SetLimitWithPolicy.
+	policy OverflowPolicy
+
+	// skipGCNil disables the nil-out of removed slots. See SetGCNilOnRemove.
+	skipGCNil bool
+
+	// noShrink disables handleShrink, initialCap is the capacity it doesn't shrink below and
+	// shrinkPolicy replaces its heuristic if set. See NewQueueWithOptions.
+	noShrink     bool
+	initialCap   int
+	shrinkPolicy ShrinkPolicy
+
+	// fifoBuf is the array backing queueSlice of Fifo queues, which keeps free slots in front of
+	// queueSlice for insertions. See insertFifo.
+	fifoBuf []Element[T]
+
+	// seq is the insertion sequence number of the element that was inserted last.
+	seq uint64
+
+	// inserted is closed and reset on every insertion to wake up blocked removers. It is nil while
+	// nobody waits.
+	inserted chan struct{}
+
+	// removed is closed and reset on every removal to wake up blocked inserters. It is nil while
+	// nobody waits.
+	removed chan struct{}
+
+	// clock decides whether elements are expired. See SetClock.
+	clock Clock
+
+	// closed is set by Close. See Close.
+	closed bool
+
+	// dedup indexes the elements by key. See SetDedup.
+	dedup *dedupIndex[T]
+
+	// onInsert and onRemove are called with the elements that enter and leave the queue.
+	onInsert []func(Element[T])
+	onRemove []func(Element[T])
+
+	// values is the chunk InsertValue takes its elements from, of which valuesUsed are taken.
+	values     *valueChunk[T]
+	valuesUsed int
+
+	// insertedAt holds the insertion times of the elements while time in queue is tracked. See
+	// SetTrackTimeInQueue.
+	insertedAt map[Element[T]]time.Time
+
+	// cmp orders the contents of Comparator queues.
+	cmp func(a, b T) int
+
+	// less orders the elements of Comparator queues built with NewQueueWithComparator instead of
+	// cmp.
+	less func(a, b Element[T]) bool
+
+	// tieBreak orders elements with equal priorities in priority queues. See SetTieBreaker.
+	tieBreak func(a, b T) bool
+
+	counters queueCounters
 }
 
 // NewQueue builds a new Queue with the passed Queuetype.
-// Since the queue is realized through a slice, expectedLength is the initial
-// cap() value of said slice.
+// Use NewQueueWithOptions to configure the initial capacity, limit and shrinking at construction.
+// Comparator queues can't be built with NewQueue, use NewQueueFunc or NewQueueWithComparator
+// instead.
 func NewQueue[T any](tp Queuetype) (*Queue[T], error) {
-	if tp < 0 || tp > numQueuetypes {
+	if tp < 0 || tp >= numQueuetypes || tp == Comparator {
 		return nil, ErrInvalidQueueType
 	}
 
@@ -72,6 +180,33 @@ func NewQueue[T any](tp Queuetype) (*Queue[T], error) {
 	}, nil
 }
 
+// NewQueueFunc builds a new Queue of Queuetype Comparator that removes the elem with the smallest
+// content according to cmp first. cmp(a, b) must return a negative number when a < b, a positive
+// number when a > b and zero when a == b. Elements with equal contents are removed oldest first.
+// The priority of the elements does not influence the order.
+func NewQueueFunc[T any](cmp func(a, b T) int) *Queue[T] {
+	return &Queue[T]{
+		order:      Comparator,
+		queueSlice: make([]Element[T], 0),
+		cmp:        cmp,
+	}
+}
+
+// NewQueueWithComparator builds a new Queue of Queuetype Comparator that removes the elem that is
+// the smallest according to less first, so that the queue can be ordered by any fields of the
+// content and by the priority. less(a, b) reports whether a is removed before b. Elements that less
+// can't tell apart are removed oldest first.
+// less must only depend on Priority and Content of the elements: the iterators and views of the
+// queue compare copies of them. Changing the priority with UpdatePriority or WithHead reorders the
+// queue.
+func NewQueueWithComparator[T any](less func(a, b Element[T]) bool) *Queue[T] {
+	return &Queue[T]{
+		order:      Comparator,
+		queueSlice: make([]Element[T], 0),
+		less:       less,
+	}
+}
+
 // NewPriorityElement builds a new Element with the passed content and priority.
 // You cannot work with the element directly. This return value is only meant to be passed to
 // queue functions.
@@ -82,6 +217,29 @@ func NewPriorityElement[T any](c T, priority float64) *PriorityElement[T] {
 	}
 }
 
+// NewPriorityElement2 builds a new Element with the passed content and priority that is ordered
+// by subPriority among the elements with the same priority, in the same direction as the priority:
+// a PriorityHigh queue removes the higher sub-priority first and a PriorityLow queue the lower, so
+// a PriorityLow queue with deadlines as sub-priorities removes the earliest deadline first.
+func NewPriorityElement2[T any](c T, priority, subPriority float64) *SubPriorityElement[T] {
+	return &SubPriorityElement[T]{
+		PriorityElement: *NewPriorityElement(c, priority),
+		subPriority:     subPriority,
+	}
+}
+
+// NewPriorityElementPtr builds a new Element with the passed priority that stores content directly
+// instead of a copy of it, which avoids copying large contents.
+// The element aliases content: changes made through content are visible through the element and
+// vice versa, so content must not be modified while the element is in a queue unless the caller
+// synchronizes that with the queue. content must not be nil.
+func NewPriorityElementPtr[T any](content *T, priority float64) *PriorityElement[T] {
+	return &PriorityElement[T]{
+		priority:    priority,
+		BaseElement: BaseElement[T]{content: content},
+	}
+}
+
 // NewBaseElement builds a new Element with the passed content and priority = 0.
 // You cannot work with the element directly. This return value is only meant to be passed to
 // queue functions.
@@ -91,18 +249,81 @@ func NewBaseElement[T any](c T) *BaseElement[T] {
 
 // Len returns the number of elements in the queue.
 func (q *Queue[T]) Len() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
 	return q.numElements
 }
 
+// Capacity returns the capacity of the slice backing the queue.
+func (q *Queue[T]) Capacity() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return q.backingCap()
+}
+
 // SetLimit sets the max capacity for the queue. Returns a ErrInvalidQueueLimit if limit < 0.
 func (q *Queue[T]) SetLimit(limit int) error {
 	if limit < 0 {
 		return ErrInvalidQueueLimit
 	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
 	q.maxnumElements = limit
+	q.notifyRemoved() // a raised limit makes room for blocked inserters.
+	return nil
+}
+
+// SetLimitWithPolicy sets the max capacity for the queue like SetLimit and the policy that Insert
+// applies once a FifoLimited queue reaches it.
+// Elements that are dropped by a policy count as evictions in the metrics.
+// LRU queues always evict their least recently used element and ignore the policy.
+// Returns a ErrInvalidQueueLimit if limit < 0 and a ErrInvalidOverflowPolicy if policy is unknown.
+func (q *Queue[T]) SetLimitWithPolicy(limit int, policy OverflowPolicy) error {
+	if policy < 0 || policy >= numOverflowPolicies {
+		return ErrInvalidOverflowPolicy
+	}
+	if err := q.SetLimit(limit); err != nil {
+		return err
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.policy = policy
 	return nil
 }
 
+// SetTieBreaker orders elements with equal priorities by less instead of by insertion age:
+// less(a, b) reports whether the content a is removed before the content b. Elements that less
+// can't tell apart are still removed oldest first. A nil less restores the ordering by age.
+// The queue is reordered in O(n log n) to honor the new order. While a tie-breaker is set,
+// insertions find their position by binary search.
+// SetTieBreaker only affects PriorityHigh and PriorityLow queues.
+func (q *Queue[T]) SetTieBreaker(less func(a, b T) bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.order != PriorityHigh && q.order != PriorityLow {
+		return
+	}
+	q.tieBreak = less
+	q.rebuildInvariant()
+}
+
+// SetGCNilOnRemove determines whether the slot of a removed element is set to nil so that the
+// garbage collector can release the element. This is enabled by default.
+// Disabling it saves a write per removal, which is only safe to do if the element contents are
+// value types that do not hold references, since otherwise the removed elements stay reachable
+// through the backing array of the queue until the slot is overwritten or the slice is shrunk.
+func (q *Queue[T]) SetGCNilOnRemove(enabled bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.skipGCNil = !enabled
+}
+
 // Append literally appends the element to the queue.
 // Append does not uphold the invariant of the queue defined by the Queuetype and is thus unsafe.
 // Use Insert for honoring the invariant.
@@ -110,8 +331,153 @@ func (q *Queue[T]) Append(elem Element[T]) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
+	capBefore := q.backingCap()
+	q.stamp(elem)
 	q.queueSlice = append(q.queueSlice, elem)
 	q.numElements++
+	q.entered(elem)
+	q.countGrow(capBefore)
+	q.counters.inserts.Add(1)
+	q.notifyInserted()
+}
+
+// AppendAll literally appends all elements to the queue in O(len(elems)).
+//
+// AppendAll does NOT uphold the invariant of the queue defined by the Queuetype. Until
+// RebuildInvariant is called, every operation that relies on the order of the queue, including
+// Insert, Remove and Peek, has undefined results.
+// It is meant for bulk loading: AppendAll followed by a single RebuildInvariant is the fastest way
+// to fill a large queue. The elements get their insertion sequence numbers in the order of elems, so
+// that RebuildInvariant treats them as inserted in that order.
+func (q *Queue[T]) AppendAll(elems []Element[T]) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	capBefore := q.backingCap()
+	for _, elem := range elems {
+		q.stamp(elem)
+	}
+	q.queueSlice = append(q.queueSlice, elems...)
+	q.numElements += len(elems)
+	for _, elem := range elems {
+		q.entered(elem)
+	}
+	q.countGrow(capBefore)
+	q.counters.inserts.Add(uint64(len(elems)))
+	q.notifyInserted()
+}
+
+// RebuildInvariant restores the invariant of the queue after it was broken by Append or AppendAll
+// in O(n log n). Elements with the same main ordering property are ordered by insertion age.
+// FifoLimited and LRU queues that exceed their limit drop their oldest elements.
+func (q *Queue[T]) RebuildInvariant() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.rebuildInvariant()
+}
+
+// ConvertTo changes the Queuetype of the queue to tp and reorders its elements for it in one
+// O(n log n) pass, e.g. to turn a Fifo queue that accumulated work into a PriorityHigh queue. The
+// elements keep their insertion age, which orders them among each other where tp has no other
+// order. A FifoLimited or LRU queue over its limit drops its oldest elements.
+// Converting drops the comparator of a Comparator queue and the tie-breaker for other Queuetypes
+// than PriorityHigh and PriorityLow.
+// Returns ErrInvalidQueueType for nonexistent Queuetypes and Comparator, which needs a comparator.
+func (q *Queue[T]) ConvertTo(tp Queuetype) error {
+	if tp < 0 || tp >= numQueuetypes || tp == Comparator {
+		return ErrInvalidQueueType
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.order = tp
+	q.cmp, q.less = nil, nil
+	if tp != PriorityHigh && tp != PriorityLow {
+		q.tieBreak = nil
+	}
+	// Fifo queues set up their headroom with the next insertion.
+	q.fifoBuf = nil
+	q.rebuildInvariant()
+	return nil
+}
+
+// Reverse flips the removal order of the queue in place in O(n): a Fifo queue becomes a Lifo queue
+// and vice versa, a PriorityHigh queue becomes a PriorityLow queue and vice versa. Elements with
+// the same priority and sub-priority are still removed oldest first, or in the order of the
+// tie-breaker.
+// Returns ErrInvalidQueueType for all other Queuetypes.
+func (q *Queue[T]) Reverse() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	switch q.order {
+	case Fifo:
+		q.order = Lifo
+	case Lifo:
+		q.order = Fifo
+	case PriorityHigh:
+		q.order = PriorityLow
+	case PriorityLow:
+		q.order = PriorityHigh
+	default:
+		return ErrInvalidQueueType
+	}
+	q.fifoBuf = nil
+	slices.Reverse(q.queueSlice)
+	if q.order != PriorityHigh && q.order != PriorityLow {
+		return nil
+	}
+
+	// reversing also flipped the ties, which keep their order.
+	for lo := 0; lo < q.numElements; {
+		hi := lo + 1
+		for hi < q.numElements && q.queueSlice[hi].Priority() == q.queueSlice[lo].Priority() &&
+			subPriorityOf(q.queueSlice[hi]) == subPriorityOf(q.queueSlice[lo]) {
+			hi++
+		}
+		slices.Reverse(q.queueSlice[lo:hi])
+		lo = hi
+	}
+	return nil
+}
+
+// Replace replaces the contents of the queue with elems in one operation and restores the
+// invariant in O(n log n). elems is copied, the caller keeps ownership of the slice.
+// The bookkeeping starts over as if the queue was built with elems: the insertion sequence numbers
+// are reassigned in the order of elems and the metrics only count the insertion of elems.
+// FifoLimited and LRU queues keep the newest elements up to their limit.
+// Like AppendAll, Replace is not affected by Close.
+func (q *Queue[T]) Replace(elems []Element[T]) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.replace(elems)
+}
+
+// replace replaces the contents of q with elems like Replace.
+// Does not lock q.
+func (q *Queue[T]) replace(elems []Element[T]) {
+	for _, elem := range q.queueSlice[:q.numElements] {
+		q.hookRemoved(elem)
+	}
+	q.counters.reset()
+	q.seq = 0
+	for _, elem := range elems {
+		q.stamp(elem)
+	}
+	q.queueSlice = append(make([]Element[T], 0, len(elems)), elems...)
+	q.fifoBuf = nil
+	q.numElements = len(elems)
+	q.counters.inserts.Add(uint64(len(elems)))
+	q.countLen()
+	q.reindex()
+	for _, elem := range elems {
+		q.hookInserted(elem)
+	}
+	q.rebuildInvariant()
+	q.notifyInserted()
+	q.notifyRemoved()
 }
 
 // Insert inserts the passed element into the queue, according to the Queuetype of the queue.
@@ -122,22 +488,242 @@ func (q *Queue[T]) Insert(elem Element[T]) error {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
+	return q.insert(elem)
+}
+
+// InsertN inserts the passed element like Insert and returns the length of the queue after the
+// insertion, read under the same lock.
+func (q *Queue[T]) InsertN(elem Element[T]) (int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	err := q.insert(elem)
+	return q.numElements, err
+}
+
+// valueChunkSize is the number of elements InsertValue allocates at once.
+const valueChunkSize = 64
+
+// valueChunk holds the elements of InsertValue and their contents, so that they are allocated
+// together instead of one by one.
+type valueChunk[T any] struct {
+	elems    [valueChunkSize]PriorityElement[T]
+	contents [valueChunkSize]T
+}
+
+// InsertValue inserts content with priority like Insert of a PriorityElement, without allocating
+// the element and its content on every insertion: they are taken from chunks that are allocated
+// for many elements at once. The elements are never reused, so they can be shared like any other
+// element, but a chunk stays reachable, including the contents of its removed elements, as long
+// as one of its elements is.
+func (q *Queue[T]) InsertValue(content T, priority float64) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.values == nil || q.valuesUsed == valueChunkSize {
+		q.values = new(valueChunk[T])
+		q.valuesUsed = 0
+	}
+	i := q.valuesUsed
+	elem := &q.values.elems[i]
+	q.values.contents[i] = content
+	elem.content = &q.values.contents[i]
+	elem.priority = priority
+	if err := q.insert(elem); err != nil {
+		// the slot is free again, since a rejected element is not referenced by q.
+		*elem = PriorityElement[T]{}
+		q.values.contents[i] = *new(T)
+		return err
+	}
+	q.valuesUsed++
+	return nil
+}
+
+// InsertAll inserts all elems like Insert under one lock. Priority and Comparator queues sort the
+// batch once and merge it into the queue in O(n + k log k) for k elems, Lifo queues append it in
+// one step. Fifo, FifoLimited and LRU queues insert the elements one by one, each in O(1)
+// amortized, so that the limit and overflow policy apply to every element.
+// elems is copied, the caller keeps ownership of the slice. elems are treated as inserted in their
+// order, so among elements with the same main ordering property the earlier ones are removed first.
+// If an element can't be inserted, the error is returned and the elements before it stay inserted.
+func (q *Queue[T]) InsertAll(elems []Element[T]) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.order < 0 || q.order >= numQueuetypes {
+		return ErrInvalidQueueType
+	}
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if q.dedup != nil {
+		// every element has to be checked against the ones before it.
+		for i, elem := range elems {
+			if err := q.insert(elem); err != nil {
+				return errors.Wrapf(err, "inserting element %d", i)
+			}
+		}
+		return nil
+	}
+	for _, elem := range elems {
+		q.stamp(elem)
+	}
+
+	switch q.order {
+	case Fifo, FifoLimited, LRU:
+		for i, elem := range elems {
+			placed, err := q.place(elem)
+			if err != nil {
+				return errors.Wrapf(err, "inserting element %d", i)
+			}
+			if placed {
+				q.hookInserted(elem)
+			}
+			q.counters.inserts.Add(1)
+		}
+		return nil
+	}
+
+	capBefore := q.backingCap()
+	if q.order == Lifo {
+		q.queueSlice = append(q.queueSlice, elems...)
+	} else {
+		q.mergeSorted(elems)
+	}
+	for _, elem := range elems {
+		q.entered(elem)
+	}
+	q.numElements += len(elems)
+	q.countGrow(capBefore)
+	q.counters.inserts.Add(uint64(len(elems)))
+	q.notifyInserted()
+	return nil
+}
+
+// Touch marks the element at index, counted in removal order like in PeekElemAtIndex, as used
+// by moving it to the most recently used position of an LRU queue. It becomes the element that is
+// removed or evicted last and gets a new insertion sequence number.
+// Returns an error of type ErrInvalidQueueType if the queue is not an LRU queue and an *IndexError,
+// which matches ErrIndexOutOfBounds, when the provided index is out of bounds.
+func (q *Queue[T]) Touch(index int) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.order != LRU {
+		return ErrInvalidQueueType
+	}
+	if index < 0 || index >= q.numElements {
+		return &IndexError{Index: index, Len: q.numElements}
+	}
+
+	// the most recently used position is the start of the slice.
+	realIndex := (q.numElements - 1) - index
+	elem := q.queueSlice[realIndex]
+	copy(q.queueSlice[1:realIndex+1], q.queueSlice[:realIndex])
+	q.queueSlice[0] = elem
+	q.stamp(elem)
+	return nil
+}
+
+// InsertIfAbsent inserts content with priority into q like Insert, unless q already holds an
+// element whose content has the same key. Existing elements, including their priorities, are left
+// unchanged. The check and the insertion happen under one lock, so concurrent callers can't admit
+// the same key twice.
+// The check scans q in O(n), since q does not keep an index of the keys. KeyedPriorityQueue does.
+// Returns whether content was inserted.
+func InsertIfAbsent[T any, K comparable](
+	q *Queue[T],
+	content T,
+	priority float64,
+	key func(T) K,
+) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	k := key(content)
+	for _, e := range q.queueSlice[:q.numElements] {
+		if key(e.Content()) == k {
+			return false, nil
+		}
+	}
+
+	if err := q.insert(NewPriorityElement(content, priority)); err != nil {
+		return false, errors.Wrap(err, "inserting absent element")
+	}
+	return true, nil
+}
+
+// insert stamps elem with the next insertion sequence number and places it in the queue.
+// Does not lock q.
+func (q *Queue[T]) insert(elem Element[T]) error {
+	if q.order < 0 || q.order >= numQueuetypes {
+		return ErrInvalidQueueType
+	}
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if err := q.checkDuplicate(elem); err != nil {
+		return err
+	}
+	q.stamp(elem)
+	placed, err := q.place(elem)
+	if err != nil {
+		return err
+	}
+	if placed {
+		q.hookInserted(elem)
+	}
+	q.counters.inserts.Add(1)
+	return nil
+}
+
+// place puts elem into the queue according to the Queuetype of the queue without touching its
+// insertion sequence number. It is used for reinsertions of elements that are already known to
+// the queue, so it does not call the insertion hooks. Reports false if the queue dropped elem
+// instead because it is full.
+// Does not lock q.
+func (q *Queue[T]) place(elem Element[T]) (bool, error) {
+	capBefore := q.backingCap()
 	switch q.order {
 	case Fifo:
 		q.insertFifo(elem)
 	case Lifo:
 		q.insertLifo(elem)
 	case PriorityHigh:
-		q.insertPriorityHigh(elem)
+		if q.tieBreak != nil {
+			q.insertSorted(elem)
+		} else {
+			q.insertPriorityHigh(elem)
+		}
 	case PriorityLow:
-		q.insertPriorityLow(elem)
-	case FifoLimited:
-		return q.insertFifoLimited(elem)
+		if q.tieBreak != nil {
+			q.insertSorted(elem)
+		} else {
+			q.insertPriorityLow(elem)
+		}
+	case FifoLimited, LRU:
+		placed, err := q.insertFifoLimited(elem)
+		if err != nil || !placed {
+			return false, err
+		}
+	case Comparator, Delayed:
+		q.insertSorted(elem)
 	default:
-		return ErrInvalidQueueType
+		return false, ErrInvalidQueueType
 	}
 	q.numElements++
-	return nil
+	q.track(elem)
+	q.countGrow(capBefore)
+	q.notifyInserted()
+	return true, nil
+}
+
+// stamp assigns the next insertion sequence number to elem if it is able to store one.
+func (q *Queue[T]) stamp(elem Element[T]) {
+	if s, ok := elem.(sequenced); ok {
+		q.seq++
+		s.setSequence(q.seq)
+	}
 }
 
 // Remove pops the element that is meant to be removed first according to the queues order.
@@ -149,13 +735,264 @@ func (q *Queue[T]) Remove() (T, float64, error) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	elem, err := q.remove(q.numElements - 1)
+	elem, err := q.removeHead()
 	if err != nil {
 		return *new(T), 0, err
 	}
 	return elem.Content(), elem.Priority(), nil
 }
 
+// RemoveN pops the element that is meant to be removed first like Remove and additionally returns
+// the length of the queue after the removal, read under the same lock.
+func (q *Queue[T]) RemoveN() (T, float64, int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	elem, err := q.removeHead()
+	if err != nil {
+		return *new(T), 0, q.numElements, err
+	}
+	return elem.Content(), elem.Priority(), q.numElements, nil
+}
+
+// RemoveBatch pops up to n elements in removal order under one lock and returns their contents in
+// removal order. The backing slice is shrunk at most once for the whole batch. Expired elements
+// among the n are dropped, so the batch can be shorter although more elements are left. Delayed
+// queues only pop the elements that are ready.
+// If the queue is empty, the error of Remove is returned. n < 1 removes nothing.
+func (q *Queue[T]) RemoveBatch(n int) ([]T, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.dropExpiredHead()
+	if q.numElements == 0 || !q.headReady(q.now()) {
+		_, err := q.removeHead()
+		return nil, err
+	}
+	removed := q.dropExpired(q.removeHeads(q.readyHeads(min(max(n, 0), q.numElements))))
+	batch := make([]T, len(removed))
+	for i, elem := range removed {
+		batch[i] = elem.Content()
+	}
+	return batch, nil
+}
+
+// RemoveByPriorityBudget pops elements in removal order, highest priority first for PriorityHigh
+// queues, as long as the sum of their priorities stays within budget, e.g. to process up to budget
+// units of work where the priority of an element is its amount of work. Priorities can be
+// fractional or negative, a negative priority makes room for further elements.
+// The first element is always popped, even if its priority exceeds budget on its own, so that
+// every call makes progress. The batch is taken under one lock and returned in removal order.
+// Expired elements are dropped and don't count towards budget. Delayed queues stop at the first
+// element that is not ready.
+// If the queue is empty or its head is not ready, the error of Remove is returned.
+func (q *Queue[T]) RemoveByPriorityBudget(budget float64) ([]T, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	elem, err := q.removeHead()
+	if err != nil {
+		return nil, err
+	}
+	batch := []T{elem.Content()}
+	sum := elem.Priority()
+	for {
+		// every pop skips expired elements like Remove and stops at a head that is not ready.
+		q.dropExpiredHead()
+		if q.numElements == 0 || !q.headReady(q.now()) {
+			break
+		}
+		next := q.queueSlice[q.numElements-1].Priority()
+		if sum+next > budget {
+			break
+		}
+		elem, err := q.remove(q.numElements - 1)
+		if err != nil {
+			return batch, errors.Wrap(err, "removing batch element")
+		}
+		batch = append(batch, elem.Content())
+		sum += next
+	}
+	return batch, nil
+}
+
+// BlockingRemove pops the element that is meant to be removed first according to the queues order,
+// like Remove. If the queue is empty it blocks until an element is inserted or ctx is done. The
+// head of a Delayed queue is awaited until it is ready.
+// Returns ctx.Err() if ctx is done before an element could be removed and ErrQueueClosed if the
+// queue is closed and drained, which also wakes up all blocked callers.
+func (q *Queue[T]) BlockingRemove(ctx context.Context) (T, float64, error) {
+	for {
+		q.lock.Lock()
+		q.dropExpiredHead()
+		now := q.now()
+		if (q.numElements > 0 && q.headReady(now)) || (q.numElements == 0 && q.closed) {
+			elem, err := q.removeHead()
+			q.lock.Unlock()
+			if err != nil {
+				return *new(T), 0, err
+			}
+			return elem.Content(), elem.Priority(), nil
+		}
+		inserted := q.waitInserted()
+		// an insertion can bring an earlier head, which is why both are awaited.
+		var ready <-chan time.Time
+		if q.numElements > 0 {
+			ready = q.after(readyAtOf(q.queueSlice[q.numElements-1]).Sub(now))
+		}
+		q.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return *new(T), 0, ctx.Err()
+		case <-inserted:
+		case <-ready:
+		}
+	}
+}
+
+// TryRemove pops the element that is meant to be removed first like Remove, but reports whether
+// there was one instead of returning an error, so that consumers can poll the queue. It never
+// blocks.
+func (q *Queue[T]) TryRemove() (T, float64, bool) {
+	c, priority, err := q.Remove()
+	return c, priority, err == nil
+}
+
+// RemoveWithTimeout pops the element that is meant to be removed first like BlockingRemove, but
+// waits at most d on the clock of q for an element.
+// Returns the error of Remove if there still is no element to remove after d, like ErrEmptyQueue
+// or ErrNotReady, and ErrQueueClosed if the queue is closed and drained.
+func (q *Queue[T]) RemoveWithTimeout(d time.Duration) (T, float64, error) {
+	q.lock.RLock()
+	timeout := q.after(d)
+	q.lock.RUnlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-timeout:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	c, priority, err := q.BlockingRemove(ctx)
+	if errors.Is(err, context.Canceled) {
+		return q.Remove()
+	}
+	return c, priority, err
+}
+
+// removeHead removes the element that is meant to be removed first. Expired elements are dropped.
+// Returns ErrQueueClosed instead of ErrEmptyQueue if the queue is closed and empty and ErrNotReady
+// if the head of a Delayed queue is not ready yet.
+// Does not lock q.
+func (q *Queue[T]) removeHead() (Element[T], error) {
+	q.dropExpiredHead()
+	if q.closed && q.numElements == 0 {
+		return nil, ErrQueueClosed
+	}
+	if q.numElements > 0 && !q.headReady(q.now()) {
+		return nil, ErrNotReady
+	}
+	return q.remove(q.numElements - 1)
+}
+
+// Close marks the queue as closed, comparable to closing a channel.
+// Afterwards Insert fails with ErrQueueClosed, while the remaining elements can still be removed.
+// Once the queue is drained, Remove returns ErrQueueClosed instead of ErrEmptyQueue and blocked
+// BlockingRemove calls return with ErrQueueClosed.
+// Append and AppendAll can't report errors and are not affected by Close.
+// Blocked BlockingInsert calls return with ErrQueueClosed as well.
+// Closing a closed queue has no effect.
+func (q *Queue[T]) Close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.closed = true
+	q.notifyInserted()
+	q.notifyRemoved()
+}
+
+// Closed reports whether Close was called on the queue.
+func (q *Queue[T]) Closed() bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return q.closed
+}
+
+// waitInserted returns a channel that is closed on the next insertion.
+// Does not lock q.
+func (q *Queue[T]) waitInserted() <-chan struct{} {
+	if q.inserted == nil {
+		q.inserted = make(chan struct{})
+	}
+	return q.inserted
+}
+
+// notifyInserted wakes up everyone waiting for an insertion.
+// Does not lock q.
+func (q *Queue[T]) notifyInserted() {
+	if q.inserted != nil {
+		close(q.inserted)
+		q.inserted = nil
+	}
+}
+
+// BlockingInsert inserts elem like Insert. If the queue is a FifoLimited or LRU queue that is at
+// its limit, it blocks until an element is removed or ctx is done instead of evicting the oldest
+// element, so that the queue applies backpressure to its producers. Other queues never block.
+// Returns ctx.Err() if ctx is done before elem could be inserted and ErrQueueClosed if the queue
+// is closed, which also wakes up all blocked callers.
+func (q *Queue[T]) BlockingInsert(ctx context.Context, elem Element[T]) error {
+	for {
+		q.lock.Lock()
+		if q.closed || !q.full() {
+			err := q.insert(elem)
+			q.lock.Unlock()
+			return err
+		}
+		removed := q.waitRemoved()
+		q.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-removed:
+		}
+	}
+}
+
+// full reports whether an insertion into q would evict an element because of its limit.
+// Does not lock q.
+func (q *Queue[T]) full() bool {
+	if q.order != FifoLimited && q.order != LRU {
+		return false
+	}
+	return q.maxnumElements != 0 && q.numElements >= q.maxnumElements
+}
+
+// waitRemoved returns a channel that is closed on the next removal.
+// Does not lock q.
+func (q *Queue[T]) waitRemoved() <-chan struct{} {
+	if q.removed == nil {
+		q.removed = make(chan struct{})
+	}
+	return q.removed
+}
+
+// notifyRemoved wakes up everyone waiting for a removal.
+// Does not lock q.
+func (q *Queue[T]) notifyRemoved() {
+	if q.removed != nil {
+		close(q.removed)
+		q.removed = nil
+	}
+}
+
 // RemoveElement pops the element that is meant to be removed first according to the queues order.
 // When there are multiple elements with the same priority the oldest elem will be the first that is
 // removed.
@@ -164,7 +1001,7 @@ func (q *Queue[T]) RemoveElement() (Element[T], error) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	elem, err := q.remove(q.numElements - 1)
+	elem, err := q.removeHead()
 	if err != nil {
 		return nil, err
 	}
@@ -172,91 +1009,517 @@ func (q *Queue[T]) RemoveElement() (Element[T], error) {
 	return elem, nil
 }
 
+// RemovePriorityElement pops the element that is meant to be removed first according to the queues
+// order, like RemoveElement, but returns it as its concrete type.
+// If the element is not a *PriorityElement, ErrElementTypeMismatch is returned and the element stays
+// in the queue.
+func (q *Queue[T]) RemovePriorityElement() (*PriorityElement[T], error) {
+	return removeTyped[T, *PriorityElement[T]](q)
+}
+
+// RemoveBaseElement pops the element that is meant to be removed first according to the queues
+// order, like RemoveElement, but returns it as its concrete type.
+// If the element is not a *BaseElement, ErrElementTypeMismatch is returned and the element stays
+// in the queue.
+func (q *Queue[T]) RemoveBaseElement() (*BaseElement[T], error) {
+	return removeTyped[T, *BaseElement[T]](q)
+}
+
+// PopMax removes the element with the highest priority from a PriorityHigh or PriorityLow queue,
+// independent of which end of the queue it is at. Ties are broken like in Remove.
+// Runs in O(1) on PriorityHigh queues. On PriorityLow queues the highest priority is found in
+// O(log n) and removed in O(1) if no other element shares it, otherwise the element is taken out
+// of the middle of the queue in O(n).
+// Returns ErrInvalidQueueType for all other Queuetypes.
+func (q *Queue[T]) PopMax() (T, float64, error) {
+	return q.popExtreme(PriorityHigh)
+}
+
+// PopMin removes the element with the lowest priority from a PriorityHigh or PriorityLow queue,
+// independent of which end of the queue it is at. Ties are broken like in Remove.
+// Runs in O(1) on PriorityLow queues. On PriorityHigh queues the lowest priority is found in
+// O(log n) and removed in O(1) if no other element shares it, otherwise the element is taken out
+// of the middle of the queue in O(n).
+// Returns ErrInvalidQueueType for all other Queuetypes.
+func (q *Queue[T]) PopMin() (T, float64, error) {
+	return q.popExtreme(PriorityLow)
+}
+
+// RemoveLast pops the element that would be removed last, so that a bounded priority queue can
+// evict its worst element: a PriorityHigh queue that keeps the top N drops its lowest priority
+// once it holds N+1 elements. Among elements with the same priority it drops the youngest, the
+// opposite of Remove. Runs in O(1) plus the shrinking like Remove.
+// Expired elements are not skipped: like RemoveAt, the last element is returned even if it has
+// expired and counts as a remove, so that evicting it discards it either way. The ready times of
+// Delayed queues are not checked either.
+// Returns ErrEmptyQueue if the list is empty and ErrQueueClosed if the queue is closed and drained.
+func (q *Queue[T]) RemoveLast() (T, float64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.numElements == 0 {
+		if q.closed {
+			return *new(T), 0, ErrQueueClosed
+		}
+		return *new(T), 0, ErrEmptyQueue
+	}
+	elem, err := q.remove(0)
+	if err != nil {
+		return *new(T), 0, err
+	}
+	return elem.Content(), elem.Priority(), nil
+}
+
+// RemoveAt pops the element at index in removal order, using the same index convention as
+// PeekElemAtIndex: RemoveAt(0) is Remove without skipping expired elements. The remaining elements
+// keep their order.
+// Returns an error of type ErrEmptyQueue when the list is empty.
+// Returns an *IndexError, which matches ErrIndexOutOfBounds, when the provided index is out of
+// bounds.
+func (q *Queue[T]) RemoveAt(index int) (T, float64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.numElements == 0 {
+		return *new(T), 0, ErrEmptyQueue
+	}
+	if index < 0 || index >= q.numElements {
+		return *new(T), 0, &IndexError{Index: index, Len: q.numElements}
+	}
+	elem, err := q.remove((q.numElements - 1) - index)
+	if err != nil {
+		return *new(T), 0, err
+	}
+	return elem.Content(), elem.Priority(), nil
+}
+
+// RemoveWhere removes all elements whose content matches pred in a single O(n) pass under one
+// lock and returns how many were removed. The remaining elements keep their order. DrainFilter
+// removes the same elements, but returns their contents.
+// Returns an error of type ErrEmptyQueue when the list is empty.
+func (q *Queue[T]) RemoveWhere(pred func(T) bool) (int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.numElements == 0 {
+		return 0, ErrEmptyQueue
+	}
+	removed := q.removeWhere(func(elem Element[T]) bool {
+		return pred(elem.Content())
+	})
+	q.counters.removes.Add(uint64(len(removed)))
+	return len(removed), nil
+}
+
+// popExtreme removes the element that a queue of Queuetype order would remove first.
+// Locks q.
+func (q *Queue[T]) popExtreme(order Queuetype) (T, float64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.order != PriorityHigh && q.order != PriorityLow {
+		return *new(T), 0, ErrInvalidQueueType
+	}
+
+	var elem Element[T]
+	var err error
+	if q.order == order || q.numElements == 0 {
+		elem, err = q.removeHead()
+	} else {
+		// the wanted extreme is at the start of the slice. The element of its block that is
+		// removed first is the last one.
+		_, hi := q.priorityBlock(q.queueSlice[0].Priority())
+		elem, err = q.remove(hi - 1)
+	}
+	if err != nil {
+		return *new(T), 0, err
+	}
+	return elem.Content(), elem.Priority(), nil
+}
+
+// removeTyped pops the head of q if it is of type E.
+// Locks q.
+func removeTyped[T any, E Element[T]](q *Queue[T]) (E, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.numElements == 0 {
+		if q.closed {
+			return *new(E), ErrQueueClosed
+		}
+		return *new(E), ErrEmptyQueue
+	}
+	if _, ok := q.queueSlice[q.numElements-1].(E); !ok {
+		return *new(E), ErrElementTypeMismatch
+	}
+
+	elem, err := q.remove(q.numElements - 1)
+	if err != nil {
+		return *new(E), err
+	}
+	return elem.(E), nil
+}
+
 // UpdatePriority updates the priority of all elements with priority oldPriority to the newPriority.
 // Upholds the invariant of the queue.
 // Returns the number of updates.
-// If performanceFlag is set, elements with the same priority will be reversed in their order for
-// ordertypes
-// PriorityHigh and PriorityLow.
+// For ordertypes PriorityHigh and PriorityLow the k elements with oldPriority are found by binary
+// search in O(log n) and only those are reinserted. The reinserted elements get new insertion
+// sequence numbers, since they are removed after the elements that already had newPriority. If
+// performanceFlag is set, they are reinserted youngest first, which reverses their order among
+// each other. Otherwise they keep their relative age. Both orders cost the same, the flag merely
+// selects the tie order.
 func (q *Queue[T]) UpdatePriority(oldPriority, newPriority float64, performanceFlag bool) int {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
 	counter := 0
 
-	var list []Element[T]
-	if !performanceFlag {
-		list = make([]Element[T], 0) // for buffering elements for reinsertion
-	}
-
 	switch q.order {
-	case Lifo, Fifo:
+	case Lifo, Fifo, FifoLimited, Comparator, LRU, Delayed:
 		for _, e := range q.queueSlice { // O(n)
 			//modifing e works because queueSlice is Element
-			//+ Lifo and Fifo both are not sorted after priority
+			//+ Lifo, Fifo and Comparator are not sorted after priority
 
 			if e.Priority() == oldPriority {
 				e.SetPriority(newPriority)
 				counter++
 			}
 		}
+		if q.less != nil && counter > 0 {
+			// the comparator of the queue may depend on the priority.
+			q.rebuildInvariant()
+		}
 
 	case PriorityHigh, PriorityLow:
-		// todo: use binsearch to find first elem with priority
-		var modFlag bool
+		// the elements with oldPriority form a contiguous block, so only that block is touched.
+		lo, hi := q.priorityBlock(oldPriority)
+		list := make([]Element[T], hi-lo) // for buffering elements for reinsertion
+		copy(list, q.queueSlice[lo:hi])
+		for _, e := range list {
+			e.SetPriority(newPriority)
+		}
 
-		for i, e := range q.queueSlice {
-			if e.Priority() == oldPriority {
-				q.deleteWithoutMemoryManagement(
-					i,
-				) // delete without MemoryManagement because elements get reinserted
-				e.SetPriority(newPriority)
-				if performanceFlag {
-					q.Insert(e) // reverses the order within elements with the same priority
-				} else {
-					list = append(list, e)
-				}
-			} else if modFlag {
-				break
+		// no nil-out of the vacated tail slots, the reinsertions below overwrite them.
+		copy(q.queueSlice[lo:], q.queueSlice[hi:])
+		q.queueSlice = q.queueSlice[:q.numElements-len(list)]
+		q.numElements = len(q.queueSlice)
+		counter = len(list)
+
+		l := len(list)
+		for i := range list {
+			e := list[l-(i+1)] // insert oldest element first
+			if performanceFlag {
+				e = list[i] // reverses the order within elements with the same priority
 			}
+			// the age of e has to match its place behind the elements with newPriority.
+			q.stamp(e)
+			q.place(e)
 		}
 	}
 
-	if (q.order == PriorityHigh || q.order == PriorityLow) && !performanceFlag {
-		l := len(list)
-		for i := range list {
-			q.Insert(list[l-(i+1)]) // insert oldest element first
+	return counter
+}
+
+// WithHead calls f with pointers to the priority and the content of the element that is meant to
+// be removed first, under lock. Whatever f writes through them is stored in the element and, if it
+// affects the order of the queue, the element is moved to its new position. It keeps its insertion
+// age, so it is placed before younger elements with the same main ordering property.
+// If f returns an error, the element is left unchanged and the error is returned.
+// Returns ErrEmptyQueue if the queue is empty.
+func (q *Queue[T]) WithHead(f func(priority *float64, content *T) error) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.numElements == 0 {
+		return ErrEmptyQueue
+	}
+	head := q.queueSlice[q.numElements-1]
+	priority, content := head.Priority(), head.Content()
+	if err := f(&priority, &content); err != nil {
+		return err
+	}
+
+	q.untrack(head)
+	head.SetContent(content)
+	q.track(head)
+	reorder := q.order == Comparator || q.tieBreak != nil
+	if priority != head.Priority() {
+		head.SetPriority(priority)
+		reorder = reorder || q.order == PriorityHigh || q.order == PriorityLow
+	}
+	if !reorder {
+		return nil
+	}
+
+	if _, err := q.deleteWithoutMemoryManagement(q.numElements - 1); err != nil {
+		return errors.Wrap(err, "removing head for reordering")
+	}
+	// only the sorted Queuetypes are reordered, insertSorted keeps the age of head.
+	capBefore := q.backingCap()
+	q.insertSorted(head)
+	q.numElements++
+	q.countGrow(capBefore)
+	return nil
+}
+
+// UpdatePriorityWhere sets the priority of all elements whose content and priority match pred to
+// newPriority, e.g. to bump all jobs of one customer. The matching elements keep their insertion
+// age. In PriorityHigh and PriorityLow queues, and Comparator queues ordered by a less function, they
+// are taken out in one pass, sorted and merged back in O(n + k log k) for k matches.
+// Returns the number of updated elements.
+func (q *Queue[T]) UpdatePriorityWhere(pred func(content T, priority float64) bool, newPriority float64) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	reorder := q.orderedByPriority()
+	var moved []Element[T]
+	kept := q.queueSlice[:0]
+	for _, elem := range q.queueSlice {
+		if !pred(elem.Content(), elem.Priority()) {
+			kept = append(kept, elem)
+			continue
+		}
+		elem.SetPriority(newPriority)
+		moved = append(moved, elem)
+		if !reorder {
+			kept = append(kept, elem)
 		}
 	}
+	if reorder && len(moved) > 0 {
+		// the elements stay in the queue, so the hooks and the index don't see them move.
+		q.queueSlice = kept
+		q.mergeSorted(moved)
+	}
+	return len(moved)
+}
 
+// RemapPriorities sets the priority of every element to f(content, oldPriority) and restores the
+// invariant of the queue with a single re-sort afterwards if its order depends on the priorities,
+// as in PriorityHigh and PriorityLow queues and Comparator queues ordered by a less function.
+// Elements that end up with equal priorities are removed oldest first.
+// Returns the number of elements whose priority changed.
+func (q *Queue[T]) RemapPriorities(f func(content T, oldPriority float64) float64) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	counter := 0
+	for _, e := range q.queueSlice {
+		if p := f(e.Content(), e.Priority()); p != e.Priority() {
+			e.SetPriority(p)
+			counter++
+		}
+	}
+
+	if counter > 0 && q.orderedByPriority() {
+		q.rebuildInvariant()
+	}
 	return counter
 }
 
-// GetAllElements returns a slice of all elements contents.
+// Reschedule removes the first element in removal order whose content matches target according
+// to eq and reinserts it with newPriority as if it were freshly inserted, so it is removed after
+// all elements with the same main ordering property. Both happen under one lock.
+// Returns whether a matching element was found.
+func (q *Queue[T]) Reschedule(target T, eq func(a, b T) bool, newPriority float64) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for i := q.numElements - 1; i >= 0; i-- {
+		elem := q.queueSlice[i]
+		if !eq(elem.Content(), target) {
+			continue
+		}
+
+		if _, err := q.deleteWithoutMemoryManagement(i); err != nil {
+			return false, errors.Wrap(err, "removing element for rescheduling")
+		}
+		elem.SetPriority(newPriority)
+		// the element is already part of the queue, so it is placed again even if the queue is
+		// closed.
+		q.stamp(elem)
+		if _, err := q.place(elem); err != nil {
+			return true, errors.Wrap(err, "reinserting rescheduled element")
+		}
+		q.counters.inserts.Add(1)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// GetAllElements returns a slice of all elements contents in the internal order of the queue,
+// which is not specified. Use ToSlice for the removal order.
 func (q *Queue[T]) GetAllElements() []T {
-	ret := make([]T, q.numElements)
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	ret := make([]T, 0, q.numElements)
 	for _, elem := range q.queueSlice {
 		ret = append(ret, elem.Content())
 	}
 	return ret
 }
 
+// ToSlice returns the contents of all elements in removal order, so that the content removed next
+// comes first. Expired elements are included, although Remove would discard them.
+// Locks q.
+func (q *Queue[T]) ToSlice() []T {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	ret := make([]T, q.numElements)
+	for i := range ret {
+		ret[i] = q.queueSlice[q.numElements-1-i].Content()
+	}
+	return ret
+}
+
+// ToElements returns all elements in removal order like ToSlice. The elements are shared with q,
+// so their priorities must only be changed through q.
+// Locks q.
+func (q *Queue[T]) ToElements() []Element[T] {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	ret := slices.Clone(q.queueSlice[:q.numElements])
+	slices.Reverse(ret)
+	return ret
+}
+
 // Clone clones the queue completely.
 // Since only the elements can be realistically copied, if the element content is a reference type
 // the original data in the queue can still be affected by changes on the new queue.
+// The clone currently has a capacity of q.Len(), like CloneCompact, but only CloneCompact
+// guarantees this.
 func (q *Queue[T]) Clone() *Queue[T] {
-	q.lock.Lock()
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return q.cloneUnsecure()
+}
+
+// CloneCompact clones the queue completely like Clone, but guarantees that the capacity of the
+// clone equals its length. This sheds any excess capacity q has accumulated. The capacity of q is
+// not affected.
+func (q *Queue[T]) CloneCompact() *Queue[T] {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	newQueue := q.cloneConfig()
+	// the backing slice is allocated with the exact length instead of relying on cloneWith.
+	newQueue.queueSlice = make([]Element[T], q.numElements)
+	newQueue.numElements = copy(newQueue.queueSlice, q.queueSlice)
+	if newQueue.dedup != nil {
+		newQueue.reindex()
+	}
+	return newQueue
+}
+
+// Cloner is implemented by contents that can duplicate themselves, so that CloneDeep can copy them
+// without a copy function.
+type Cloner[T any] interface {
+	Clone() T
+}
+
+// CloneDeep clones the queue like Clone, but also copies the elements and their contents, so that
+// the clone is fully independent of q. Each content is copied with copyContent or, if copyContent
+// is nil, with its Clone method if T implements Cloner[T]. Other contents are copied by assignment.
+// The copies keep the priorities, deadlines and insertion age of the elements. Elements that are
+// not elements of this package become PriorityElements.
+func (q *Queue[T]) CloneDeep(copyContent func(T) T) *Queue[T] {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if copyContent == nil {
+		copyContent = cloneContent[T]
+	}
+	copies := make([]Element[T], len(q.queueSlice))
+	for i, elem := range q.queueSlice {
+		copies[i] = copyElement(elem)
+		copies[i].SetContent(copyContent(elem.Content()))
+	}
+	return q.cloneWith(copies)
+}
+
+// cloneContent copies c with its Clone method if it implements Cloner[T] and by assignment
+// otherwise.
+func cloneContent[T any](c T) T {
+	if cloner, ok := any(c).(Cloner[T]); ok {
+		return cloner.Clone()
+	}
+	return c
+}
+
+// cloneUnsecure clones the queue with a backing slice whose capacity equals its length.
+// Does not lock q.
+func (q *Queue[T]) cloneUnsecure() *Queue[T] {
+	return q.cloneWith(q.queueSlice)
+}
+
+// CloneCOW clones the queue like Clone in O(1) by sharing the backing array with the clone until
+// one of them is mutated, at which point the mutated queue copies the array first. This makes
+// frequent read-only snapshots of large queues affordable, for example for periodic reporting.
+// Both queues copy on their next mutation, even if the other one was discarded or already has its
+// own copy. If SetDedup is enabled, the index of the clone is built in O(n).
+func (q *Queue[T]) CloneCOW() *Queue[T] {
+	// the write lock guards beforeWrite, but must not copy a backing array that is already shared.
+	q.lock.lockKeepingShared()
 	defer q.lock.Unlock()
 
+	newQueue := q.cloneConfig()
+	newQueue.queueSlice = q.queueSlice
+	newQueue.numElements = q.numElements
+	newQueue.lock.beforeWrite = newQueue.unshare
+	q.lock.beforeWrite = q.unshare
+	if newQueue.dedup != nil {
+		newQueue.reindex()
+	}
+	return newQueue
+}
+
+// unshare gives q a copy of its backing array, which it may share with a CloneCOW clone.
+// Does not lock q.
+func (q *Queue[T]) unshare() {
+	q.queueSlice = slices.Clone(q.queueSlice)
+	q.fifoBuf = nil
+}
+
+// cloneWith builds a queue with the configuration of q that holds a copy of elems, which must
+// uphold the invariant of q. The capacity of the backing slice equals its length.
+// Does not lock q.
+func (q *Queue[T]) cloneWith(elems []Element[T]) *Queue[T] {
+	newQueue := q.cloneConfig()
+	newQueue.queueSlice = make([]Element[T], len(elems))
+	newQueue.numElements = len(elems)
+	copy(newQueue.queueSlice, elems)
+	if newQueue.dedup != nil {
+		newQueue.reindex()
+	}
+	return newQueue
+}
+
+// cloneConfig builds an empty queue with the configuration of q.
+// Does not lock q.
+func (q *Queue[T]) cloneConfig() *Queue[T] {
 	newQueue := &Queue[T]{
 		order:          q.order,
-		queueSlice:     make([]Element[T], q.numElements),
-		numElements:    q.numElements,
+		queueSlice:     make([]Element[T], 0),
 		maxnumElements: q.maxnumElements,
-		lock:           sync.Mutex{},
+		policy:         q.policy,
+		skipGCNil:      q.skipGCNil,
+		noShrink:       q.noShrink,
+		initialCap:     q.initialCap,
+		shrinkPolicy:   q.shrinkPolicy,
+		seq:            q.seq,
+		cmp:            q.cmp,
+		less:           q.less,
+		tieBreak:       q.tieBreak,
+		clock:          q.clock,
+		lock:           queueLock{disabled: q.lock.disabled},
+	}
+	if q.dedup != nil {
+		newQueue.dedup = &dedupIndex[T]{key: q.dedup.key, mode: q.dedup.mode}
 	}
-
-	copy(newQueue.queueSlice, q.queueSlice)
-
 	return newQueue
 }
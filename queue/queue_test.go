@@ -1 +1,1351 @@
 package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestCloneCompact(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 2000; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64((i*7)%13))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < 1500; i++ {
+			if _, _, err := q.Remove(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < 37; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64(i%5))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		srcCap := q.Capacity()
+		clone := q.CloneCompact()
+		if clone.Capacity() != clone.Len() {
+			t.Errorf("queuetype %v: expected clone capacity %d, got %d", tp, clone.Len(), clone.Capacity())
+		}
+		if clone.Len() != q.Len() {
+			t.Errorf("queuetype %v: expected clone length %d, got %d", tp, q.Len(), clone.Len())
+		}
+		if q.Capacity() != srcCap {
+			t.Errorf("queuetype %v: expected source capacity %d, got %d", tp, srcCap, q.Capacity())
+		}
+
+		for q.Len() > 0 {
+			wantContent, wantPrio, _ := q.Remove()
+			gotContent, gotPrio, err := clone.Remove()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotContent != wantContent || gotPrio != wantPrio {
+				t.Errorf("queuetype %v: expected (%d, %v), got (%d, %v)",
+					tp, wantContent, wantPrio, gotContent, gotPrio)
+			}
+		}
+	}
+}
+
+func TestRemoveTypedElement(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[string](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Insert(NewPriorityElement("prio", 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Insert(NewBaseElement("base")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := q.RemoveBaseElement(); !errors.Is(err, ErrElementTypeMismatch) {
+		t.Errorf("expected %v, got %v", ErrElementTypeMismatch, err)
+	}
+	if q.Len() != 2 {
+		t.Errorf("expected mismatch to keep the element, got length %d", q.Len())
+	}
+	pe, err := q.RemovePriorityElement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pe.Content() != "prio" || pe.Priority() != 2 {
+		t.Errorf("expected (prio, 2), got (%s, %v)", pe.Content(), pe.Priority())
+	}
+
+	if _, err := q.RemovePriorityElement(); !errors.Is(err, ErrElementTypeMismatch) {
+		t.Errorf("expected %v, got %v", ErrElementTypeMismatch, err)
+	}
+	be, err := q.RemoveBaseElement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if be.Content() != "base" {
+		t.Errorf("expected base, got %s", be.Content())
+	}
+
+	if _, err := q.RemoveBaseElement(); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+}
+
+func TestReschedule(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[string](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []struct {
+		c string
+		p float64
+	}{{"a", 5}, {"b", 3}, {"c", 3}, {"d", 1}} {
+		if err := q.Insert(NewPriorityElement(e.c, e.p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eq := func(a, b string) bool { return a == b }
+
+	// d moves up to the priority of b and c, behind both as a fresh insert.
+	if found, err := q.Reschedule("d", eq, 3); !found || err != nil {
+		t.Fatalf("expected match, got %v, %v", found, err)
+	}
+	// b moves to the very back.
+	if found, err := q.Reschedule("b", eq, 0); !found || err != nil {
+		t.Fatalf("expected match, got %v, %v", found, err)
+	}
+	if found, err := q.Reschedule("x", eq, 9); found || err != nil {
+		t.Errorf("expected no match, got %v, %v", found, err)
+	}
+
+	want := []string{"a", "c", "d", "b"}
+	if got := drain(t, q); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// naiveUpdatePriority is the reference for UpdatePriority on priority queues: it scans the whole
+// queue and reinserts the matching elements oldest first.
+func naiveUpdatePriority[T any](q *Queue[T], oldPriority, newPriority float64) int {
+	var list []Element[T]
+	for i := q.numElements - 1; i >= 0; i-- {
+		if e := q.queueSlice[i]; e.Priority() == oldPriority {
+			_, _ = q.deleteWithoutMemoryManagement(i)
+			e.SetPriority(newPriority)
+			list = append(list, e)
+		}
+	}
+	for _, e := range list {
+		_, _ = q.place(e)
+	}
+	return len(list)
+}
+
+func TestUpdatePriorityMatchesNaive(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		for _, update := range [][2]float64{{3, 0}, {3, 9}, {0, 4}, {8, 8}, {42, 1}} {
+			q, _ := NewQueue[int](tp)
+			ref, _ := NewQueue[int](tp)
+			for i := 0; i < 100; i++ {
+				_ = q.Insert(NewPriorityElement(i, float64((i*7)%9)))
+				_ = ref.Insert(NewPriorityElement(i, float64((i*7)%9)))
+			}
+
+			got := q.UpdatePriority(update[0], update[1], false)
+			want := naiveUpdatePriority(ref, update[0], update[1])
+			if got != want {
+				t.Errorf("queuetype %v, update %v: expected %d updates, got %d", tp, update, want, got)
+			}
+			if g, w := drain(t, q), drain(t, ref); !equalContents(g, w) {
+				t.Errorf("queuetype %v, update %v: expected %v, got %v", tp, update, w, g)
+			}
+		}
+	}
+}
+
+func BenchmarkUpdatePriority(b *testing.B) {
+	q, _ := NewQueue[int](PriorityHigh)
+	elems := make([]Element[int], 100000)
+	for i := range elems {
+		// blocks of 10 elements per priority.
+		elems[i] = NewPriorityElement(i, float64(i/10))
+	}
+	q.AppendAll(elems)
+	q.RebuildInvariant()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%2 == 0 {
+			q.UpdatePriority(5000, -1, false)
+		} else {
+			q.UpdatePriority(-1, 5000, false)
+		}
+	}
+}
+
+func TestInsertNRemoveN(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		n, err := q.InsertN(NewBaseElement(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != i+1 || n != q.Len() {
+			t.Errorf("expected length %d, got %d", q.Len(), n)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		c, _, n, err := q.RemoveN()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != i || n != 2-i || n != q.Len() {
+			t.Errorf("expected (%d, %d), got (%d, %d)", i, q.Len(), c, n)
+		}
+	}
+	if _, _, n, err := q.RemoveN(); !errors.Is(err, ErrEmptyQueue) || n != 0 {
+		t.Errorf("expected (0, %v), got (%d, %v)", ErrEmptyQueue, n, err)
+	}
+}
+
+func TestInsertNRemoveNConcurrent(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Lifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, ops = 8, 200
+	lengths := make(chan int, workers*ops)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				n, _ := q.InsertN(NewBaseElement(i))
+				lengths <- n
+			}
+		}()
+	}
+	wg.Wait()
+	close(lengths)
+
+	// every insertion observed a distinct length.
+	seen := make(map[int]bool)
+	for n := range lengths {
+		if seen[n] {
+			t.Fatalf("length %d observed twice", n)
+		}
+		seen[n] = true
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				_, _, n, err := q.RemoveN()
+				if err != nil || n < 0 {
+					t.Errorf("unexpected (%d, %v)", n, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if q.Len() != 0 {
+		t.Errorf("expected empty queue, got length %d", q.Len())
+	}
+}
+
+func TestInsertAllMatchesInsert(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh, PriorityLow, FifoLimited} {
+		batched, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		single, _ := NewQueue[int](tp)
+		_ = batched.SetLimit(8)
+		_ = single.SetLimit(8)
+
+		for round := 0; round < 3; round++ {
+			var elems []Element[int]
+			for i := 0; i < 5; i++ {
+				c := round*5 + i
+				p := float64(c * 7 % 4)
+				elems = append(elems, NewPriorityElement(c, p))
+				if err := single.Insert(NewPriorityElement(c, p)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := batched.InsertAll(elems); err != nil {
+				t.Fatalf("queuetype %v: %v", tp, err)
+			}
+		}
+
+		if m := batched.Metrics(); m.Inserts != 15 {
+			t.Errorf("queuetype %v: expected 15 inserts, got %d", tp, m.Inserts)
+		}
+		want := drain(t, single)
+		if got := drain(t, batched); !equalContents(got, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, got)
+		}
+	}
+}
+
+func TestInsertAllClosed(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+	if err := q.InsertAll([]Element[int]{NewBaseElement(1)}); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+}
+
+func TestRemoveBatch(t *testing.T) {
+	t.Parallel()
+	q := fifoOf(t, 0, 1, 2, 3, 4)
+	got, err := q.RemoveBatch(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1, 2}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	got, err = q.RemoveBatch(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{3, 4}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if m := q.Metrics(); m.Removes != 5 {
+		t.Errorf("expected 5 removes, got %d", m.Removes)
+	}
+	if _, err := q.RemoveBatch(1); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+}
+
+// tags is a content that copies itself for CloneDeep.
+type tags struct {
+	names []string
+}
+
+func (t *tags) Clone() *tags {
+	return &tags{names: append([]string(nil), t.names...)}
+}
+
+func TestCloneDeep(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[*tags](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"a", "b", "c"} {
+		if err := q.Insert(NewPriorityElement2(&tags{names: []string{name}}, 1, float64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clone := q.CloneDeep(nil)
+	_, head, _ := clone.PeekElem()
+	head.names[0] = "x"
+	clone.queueSlice[0].SetPriority(5)
+	if _, head, _ := q.PeekElem(); head.names[0] != "c" {
+		t.Errorf("expected the head of q to be unchanged, got %v", head.names)
+	}
+	if _, ok := clone.queueSlice[0].(*SubPriorityElement[*tags]); !ok {
+		t.Errorf("expected a SubPriorityElement, got %T", clone.queueSlice[0])
+	}
+	if p := q.queueSlice[0].Priority(); p != 1 {
+		t.Errorf("expected priority 1, got %v", p)
+	}
+
+	// a copy function takes precedence over Clone.
+	shared := q.CloneDeep(func(c *tags) *tags { return c })
+	if _, head, _ := shared.PeekElem(); head != q.queueSlice[q.Len()-1].Content() {
+		t.Errorf("expected the copy function to be used")
+	}
+}
+
+func TestCloneCOW(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 100; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64(i%7))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want := drain(t, q.Clone())
+
+		clone := q.CloneCOW()
+		if &clone.queueSlice[0] != &q.queueSlice[0] {
+			t.Errorf("queuetype %v: expected the clone to share the backing array", tp)
+		}
+		// a second clone before any mutation shares the array as well.
+		if again := q.CloneCOW(); &again.queueSlice[0] != &q.queueSlice[0] {
+			t.Errorf("queuetype %v: expected the second clone to share the backing array", tp)
+		}
+
+		var wg sync.WaitGroup
+		var got []int
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got = drain(t, clone)
+		}()
+		for i := 0; i < 100; i++ {
+			if err := q.Insert(NewPriorityElement(-i, 3)); err != nil {
+				t.Error(err)
+			}
+		}
+		wg.Wait()
+
+		if !equalContents(got, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, got)
+		}
+		if q.Len() != 200 {
+			t.Errorf("queuetype %v: expected length 200, got %d", tp, q.Len())
+		}
+	}
+}
+
+func TestToSlice(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh, PriorityLow} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range []float64{2, 5, 1, 5, 3} {
+			if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		contents := q.ToSlice()
+		elems := q.ToElements()
+		want := drain(t, q.Clone())
+		if !equalContents(contents, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, contents)
+		}
+		for i, elem := range elems {
+			if elem.Content() != want[i] {
+				t.Errorf("queuetype %v: expected %d at %d, got %d", tp, want[i], i, elem.Content())
+			}
+		}
+	}
+}
+
+func TestRemapPriorities(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range []float64{1, 4, 2, 8, 5} {
+		if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a decreasing linear transform reverses the order.
+	if n := q.RemapPriorities(func(_ int, p float64) float64 { return 10 - 2*p }); n != 5 {
+		t.Errorf("expected 5 changes, got %d", n)
+	}
+	clone := q.Clone()
+	if got, want := drain(t, clone), []int{0, 2, 1, 4, 3}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// a step function makes priorities equal, which are then removed oldest first.
+	n := q.RemapPriorities(func(_ int, p float64) float64 {
+		if p > 0 {
+			return 1
+		}
+		return 0
+	})
+	if n != 4 {
+		t.Errorf("expected 4 changes, got %d", n)
+	}
+	if got, want := drain(t, q), []int{0, 1, 2, 3, 4}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRemapPrioritiesComparator(t *testing.T) {
+	t.Parallel()
+	q := NewQueueWithComparator(func(a, b Element[int]) bool { return a.Priority() < b.Priority() })
+	for i, p := range []float64{1, 4, 2, 8, 5} {
+		if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := q.RemapPriorities(func(_ int, p float64) float64 { return -p }); n != 5 {
+		t.Errorf("expected 5 changes, got %d", n)
+	}
+	if got, want := drain(t, q), []int{3, 4, 1, 2, 0}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := q.Insert(NewPriorityElement(i, float64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q.Close()
+	if !q.Closed() {
+		t.Errorf("expected queue to be closed")
+	}
+	if err := q.Insert(NewPriorityElement(5, 5)); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+	if q.Len() != 3 {
+		t.Errorf("expected length 3, got %d", q.Len())
+	}
+	// elements that are part of the queue already can still be moved.
+	eq := func(a, b int) bool { return a == b }
+	if found, err := q.Reschedule(2, eq, 2); !found || err != nil {
+		t.Errorf("expected rescheduling to succeed, got %v, %v", found, err)
+	}
+
+	// the remaining elements can still be drained.
+	for want := 2; want >= 0; want-- {
+		got, _, err := q.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("expected %d, got %d", want, got)
+		}
+	}
+	if _, _, err := q.Remove(); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+	if _, err := q.RemoveElement(); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+	if _, _, err := q.BlockingRemove(context.Background()); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+}
+
+func TestCloseWakesBlockingRemove(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const removers = 4
+	errs := make(chan error, removers)
+	for i := 0; i < removers; i++ {
+		go func() {
+			_, _, err := q.BlockingRemove(context.Background())
+			errs <- err
+		}()
+	}
+	// one element is handed to one of the blocked removers, the others wake up on Close.
+	if err := q.Insert(NewBaseElement(1)); err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+
+	closed := 0
+	for i := 0; i < removers; i++ {
+		err := <-errs
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrQueueClosed):
+			closed++
+		default:
+			t.Errorf("expected nil or %v, got %v", ErrQueueClosed, err)
+		}
+	}
+	if closed != removers-1 {
+		t.Errorf("expected %d removers to see the closed queue, got %d", removers-1, closed)
+	}
+}
+
+// TestInsertValue does not run in parallel, since AllocsPerRun counts the allocations of all
+// goroutines.
+func TestInsertValue(t *testing.T) {
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range []float64{1, 3, 2, 3} {
+		if err := q.InsertValue(i, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := drain(t, q), []int{1, 3, 2, 0}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	for i := 0; i < 1000; i++ {
+		_ = q.InsertValue(i, float64(i%10))
+	}
+	allocs := testing.AllocsPerRun(1000, func() {
+		_ = q.InsertValue(1, 5)
+		_, _, _ = q.Remove()
+	})
+	if allocs >= 0.1 {
+		t.Errorf("expected amortized allocations below 0.1, got %v", allocs)
+	}
+
+	q.Close()
+	if err := q.InsertValue(0, 0); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+}
+
+func TestTryRemove(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := q.TryRemove(); ok {
+		t.Error("expected no element")
+	}
+	if err := q.Insert(NewPriorityElement(1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if c, p, ok := q.TryRemove(); !ok || c != 1 || p != 2 {
+		t.Errorf("expected (1, 2, true), got (%d, %v, %v)", c, p, ok)
+	}
+}
+
+func TestRemoveWithTimeout(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := newFakeClock()
+	q.SetClock(clock)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, _, err := q.RemoveWithTimeout(time.Second)
+		errs <- err
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if err := <-errs; !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+
+	got := make(chan int, 1)
+	go func() {
+		c, _, err := q.RemoveWithTimeout(time.Second)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- c
+	}()
+	clock.BlockUntil(1)
+	if err := q.Insert(NewBaseElement(7)); err != nil {
+		t.Fatal(err)
+	}
+	if c := <-got; c != 7 {
+		t.Errorf("expected 7, got %d", c)
+	}
+}
+
+func TestBlockingInsert(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](FifoLimited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetLimit(2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := q.BlockingInsert(context.Background(), NewBaseElement(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.BlockingInsert(ctx, NewBaseElement(2)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.BlockingInsert(context.Background(), NewBaseElement(2))
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("insert into the full queue returned early with %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	got, _, err := q.Remove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 0 {
+		t.Errorf("expected 0, got %d", got)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing was evicted while the inserter waited.
+	want := []int{1, 2}
+	for _, w := range want {
+		got, _, err := q.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != w {
+			t.Errorf("expected %d, got %d", w, got)
+		}
+	}
+}
+
+func TestCloseWakesBlockingInsert(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](FifoLimited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetLimit(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Insert(NewBaseElement(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.BlockingInsert(context.Background(), NewBaseElement(1))
+	}()
+	q.Close()
+	if err := <-done; !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+	if q.Len() != 1 {
+		t.Errorf("expected length 1, got %d", q.Len())
+	}
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if err := q.Insert(NewPriorityElement(-i, float64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if _, _, err := q.Remove(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	elems := []Element[int]{
+		NewPriorityElement(3, 3),
+		NewPriorityElement(1, 1),
+		NewPriorityElement(20, 2),
+		NewPriorityElement(0, 0),
+		NewPriorityElement(21, 2),
+	}
+	q.Replace(elems)
+
+	if m := q.Metrics(); m != (QueueMetrics{Inserts: uint64(len(elems))}) {
+		t.Errorf("expected metrics to be reset, got %+v", m)
+	}
+	// the caller's slice must not be reordered.
+	if elems[0].Content() != 3 {
+		t.Errorf("expected elems to be untouched, got %v at 0", elems[0].Content())
+	}
+
+	want := []int{0, 1, 20, 21, 3}
+	if got := drain(t, q); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestReplaceFifoLimited(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](FifoLimited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetLimit(3); err != nil {
+		t.Fatal(err)
+	}
+	_ = q.Insert(NewBaseElement(100))
+
+	var elems []Element[int]
+	for i := 0; i < 5; i++ {
+		elems = append(elems, NewBaseElement(i))
+	}
+	q.Replace(elems)
+
+	want := []int{2, 3, 4}
+	if got := drain(t, q); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestNewPriorityElementPtr(t *testing.T) {
+	t.Parallel()
+	content := &task{deadline: 1, name: "a"}
+	q, err := NewQueue[task](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Insert(NewPriorityElementPtr(content, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the element aliases content instead of holding a copy.
+	content.deadline = 5
+	elem, err := q.RemovePriorityElement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elem.Priority() != 2 {
+		t.Errorf("expected priority 2, got %v", elem.Priority())
+	}
+	if got := elem.Content().deadline; got != 5 {
+		t.Errorf("expected the change through the pointer to be visible, got deadline %d", got)
+	}
+	if elem.content != content {
+		t.Errorf("expected the element to store the passed pointer")
+	}
+}
+
+type largeContent [4096]byte
+
+var sinkElement *PriorityElement[largeContent]
+
+func BenchmarkNewPriorityElementLarge(b *testing.B) {
+	content := new(largeContent)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sinkElement = NewPriorityElement(*content, 1)
+	}
+}
+
+func BenchmarkNewPriorityElementPtrLarge(b *testing.B) {
+	content := new(largeContent)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sinkElement = NewPriorityElementPtr(content, 1)
+	}
+}
+
+func TestPopMaxPopMin(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// contents are priority*10 + insertion index within the priority.
+		for _, c := range []int{20, 50, 10, 51, 11, 30, 12, 52} {
+			if err := q.Insert(NewPriorityElement(c, float64(c/10))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, step := range []struct {
+			max  bool
+			want int
+		}{
+			{true, 50}, {false, 10}, {false, 11}, {true, 51}, {true, 52}, {false, 12}, {true, 30}, {false, 20},
+		} {
+			pop := q.PopMin
+			if step.max {
+				pop = q.PopMax
+			}
+			got, prio, err := pop()
+			if err != nil {
+				t.Fatalf("queuetype %v: %v", tp, err)
+			}
+			if got != step.want || prio != float64(step.want/10) {
+				t.Errorf("queuetype %v, max %v: expected %d, got %d with priority %v", tp, step.max, step.want, got, prio)
+			}
+		}
+
+		if _, _, err := q.PopMin(); !errors.Is(err, ErrEmptyQueue) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrEmptyQueue, err)
+		}
+	}
+
+	q, _ := NewQueue[int](Fifo)
+	_ = q.Insert(NewBaseElement(1))
+	if _, _, err := q.PopMax(); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+}
+
+func TestPopMinKeepsOrder(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []int{3, 1, 4, 1, 5, 9, 2, 6} {
+		_ = q.Insert(NewPriorityElement(c, float64(c)))
+	}
+	_, _, _ = q.PopMin()
+	_, _, _ = q.PopMin()
+
+	want := []int{9, 6, 5, 4, 3, 2}
+	if got := drain(t, q); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSetTieBreaker(t *testing.T) {
+	t.Parallel()
+	alphabetical := func(a, b string) bool { return a < b }
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		q, err := NewQueue[string](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q.SetTieBreaker(alphabetical)
+		for _, e := range []struct {
+			c string
+			p float64
+		}{{"d", 1}, {"b", 1}, {"z", 0}, {"c", 1}, {"a", 0}, {"b", 1}, {"a", 1}} {
+			if err := q.Insert(NewPriorityElement(e.c, e.p)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		want := []string{"a", "b", "b", "c", "d", "a", "z"}
+		if tp == PriorityLow {
+			want = []string{"a", "z", "a", "b", "b", "c", "d"}
+		}
+		if got := drain(t, q); !equalContents(got, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, got)
+		}
+	}
+}
+
+func TestSetTieBreakerReorders(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[string](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{"c", "a", "b"} {
+		_ = q.Insert(NewPriorityElement(c, 1))
+	}
+	q.SetTieBreaker(func(a, b string) bool { return a < b })
+	if _, c, _ := q.PeekElem(); c != "a" {
+		t.Errorf("expected a at the head, got %v", c)
+	}
+
+	// without a tie-breaker the queue falls back to insertion age.
+	q.SetTieBreaker(nil)
+	want := []string{"c", "a", "b"}
+	if got := drain(t, q); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestInsertIfAbsent(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[task](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := func(c task) string { return c.name }
+
+	for _, c := range []struct {
+		content  task
+		priority float64
+		want     bool
+	}{
+		{task{deadline: 1, name: "a"}, 1, true},
+		{task{deadline: 2, name: "b"}, 2, true},
+		{task{deadline: 3, name: "a"}, 5, false},
+		{task{deadline: 4, name: "c"}, 0, true},
+		{task{deadline: 5, name: "b"}, 9, false},
+	} {
+		inserted, err := InsertIfAbsent(q, c.content, c.priority, byName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inserted != c.want {
+			t.Errorf("%v: expected inserted %v, got %v", c.content, c.want, inserted)
+		}
+	}
+
+	// the first admitted element of each key stays with its priority.
+	want := []task{{2, "b"}, {1, "a"}, {4, "c"}}
+	if got := drain(t, q); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	q.Close()
+	if _, err := InsertIfAbsent(q, task{name: "d"}, 0, byName); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+}
+
+func TestWithHead(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[string](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []struct {
+		c string
+		p float64
+	}{{"a", 5}, {"b", 3}, {"c", 2}, {"d", 3}} {
+		_ = q.Insert(NewPriorityElement(e.c, e.p))
+	}
+
+	// bump the head down to the priority of b and d. It is older than both, so it stays in front.
+	err = q.WithHead(func(priority *float64, content *string) error {
+		if *priority != 5 || *content != "a" {
+			t.Errorf("expected head a with priority 5, got %s with %v", *content, *priority)
+		}
+		*priority = 3
+		*content = "A"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, c, _ := q.PeekElem(); c != "A" {
+		t.Errorf("expected A to stay the head, got %s", c)
+	}
+
+	// bump it below all of its neighbors.
+	err = q.WithHead(func(priority *float64, content *string) error {
+		*priority = 1
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bail := errors.New("bail")
+	err = q.WithHead(func(priority *float64, content *string) error {
+		*priority = 0
+		return bail
+	})
+	if !errors.Is(err, bail) {
+		t.Errorf("expected %v, got %v", bail, err)
+	}
+
+	want := []string{"b", "d", "c", "A"}
+	if got := drain(t, q); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if err := q.WithHead(func(*float64, *string) error { return nil }); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+}
+
+func TestLRU(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[string](LRU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetLimit(3); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{"a", "b", "c"} {
+		if err := q.Insert(NewBaseElement(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a is the least recently used element, touching it makes b the next one to go.
+	if err := q.Touch(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Insert(NewBaseElement("d")); err != nil {
+		t.Fatal(err)
+	}
+	// b was evicted, which leaves c, a, d in removal order. Touching a leaves c to go next.
+	if err := q.Touch(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Insert(NewBaseElement("e")); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := q.Metrics(); m.Evictions != 2 {
+		t.Errorf("expected 2 evictions, got %d", m.Evictions)
+	}
+	want := []string{"d", "a", "e"}
+	if got := drain(t, q); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestLRUTouchErrors(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](LRU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = q.Insert(NewBaseElement(1))
+	for _, i := range []int{-1, 1} {
+		if err := q.Touch(i); !errors.Is(err, ErrIndexOutOfBounds) {
+			t.Errorf("index %d: expected %v, got %v", i, ErrIndexOutOfBounds, err)
+		}
+	}
+
+	fifo := fifoOf(t, 1)
+	if err := fifo.Touch(0); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+}
+
+func TestLRUTouchSurvivesRebuild(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](LRU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		_ = q.Insert(NewBaseElement(i))
+	}
+	if err := q.Touch(0); err != nil {
+		t.Fatal(err)
+	}
+	// the touch is part of the sequence numbers, so restoring the invariant keeps it.
+	q.RebuildInvariant()
+
+	want := []int{1, 2, 3, 0}
+	if got := drain(t, q); !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRemoveByPriorityBudget(t *testing.T) {
+	t.Parallel()
+	priorities := []float64{5, 0.5, 2.5, -1, 8, 3}
+	build := func() *Queue[float64] {
+		q, err := NewQueue[float64](PriorityHigh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range priorities {
+			_ = q.Insert(NewPriorityElement(p, p))
+		}
+		return q
+	}
+
+	// removal order is 8, 5, 3, 2.5, 0.5, -1.
+	cases := []struct {
+		budget    float64
+		batch     []float64
+		remaining []float64
+	}{
+		{1, []float64{8}, []float64{5, 3, 2.5, 0.5, -1}},
+		{13, []float64{8, 5}, []float64{3, 2.5, 0.5, -1}},
+		{18.4, []float64{8, 5, 3}, []float64{2.5, 0.5, -1}},
+		{18.5, []float64{8, 5, 3, 2.5}, []float64{0.5, -1}},
+		// the negative priority gives back room.
+		{19, []float64{8, 5, 3, 2.5, 0.5, -1}, nil},
+	}
+	for _, c := range cases {
+		q := build()
+		batch, err := q.RemoveByPriorityBudget(c.budget)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalContents(batch, c.batch) {
+			t.Errorf("budget %v: expected batch %v, got %v", c.budget, c.batch, batch)
+		}
+		if got := drain(t, q); !equalContents(got, c.remaining) {
+			t.Errorf("budget %v: expected remaining %v, got %v", c.budget, c.remaining, got)
+		}
+	}
+
+	empty, _ := NewQueue[int](PriorityHigh)
+	if _, err := empty.RemoveByPriorityBudget(10); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+}
+
+func TestRemoveByPriorityBudgetTimed(t *testing.T) {
+	t.Parallel()
+	clock := newFakeClock()
+	q, err := NewQueue[float64](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.SetClock(clock)
+	start := clock.Now()
+	for _, elem := range []Element[float64]{
+		NewPriorityElement(8.0, 8),
+		NewPriorityElementWithExpiry(5.0, 5, start.Add(time.Second)),
+		NewPriorityElement(3.0, 3),
+	} {
+		if err := q.Insert(elem); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock.Advance(time.Second)
+	// the expired element is dropped instead of exhausting the budget.
+	batch, err := q.RemoveByPriorityBudget(11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{8, 3}; !equalContents(batch, want) {
+		t.Errorf("expected batch %v, got %v", want, batch)
+	}
+	if m := q.Metrics(); m.Expirations != 1 {
+		t.Errorf("expected 1 expiration, got %d", m.Expirations)
+	}
+
+	d, _ := NewQueue[string](Delayed)
+	d.SetClock(clock)
+	start = clock.Now()
+	for _, e := range []struct {
+		content string
+		delay   time.Duration
+	}{{"a", time.Second}, {"b", time.Second}, {"c", 3 * time.Second}} {
+		if err := d.Insert(NewDelayedElement(e.content, start.Add(e.delay))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := d.RemoveByPriorityBudget(10); !errors.Is(err, ErrNotReady) {
+		t.Errorf("expected %v, got %v", ErrNotReady, err)
+	}
+	clock.Advance(time.Second)
+	got, err := d.RemoveByPriorityBudget(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b"}; !equalContents(got, want) {
+		t.Errorf("expected batch %v, got %v", want, got)
+	}
+	if d.Len() != 1 {
+		t.Errorf("expected the element that is not ready to stay, got length %d", d.Len())
+	}
+}
+
+func TestRemoveLastKeepsTopN(t *testing.T) {
+	t.Parallel()
+	const n = 3
+	q, err := NewQueue[string](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		content  string
+		priority float64
+	}{{"a", 5}, {"b", 1}, {"c", 7}, {"d", 5}, {"e", 2}, {"f", 5}} {
+		if err := q.Insert(NewPriorityElement(c.content, c.priority)); err != nil {
+			t.Fatal(err)
+		}
+		if q.Len() > n {
+			if _, _, err := q.RemoveLast(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// "f" ties with "a" and "d" and is dropped as the youngest.
+	if p, c, err := q.PeekLast(); err != nil || c != "d" || p != 5 {
+		t.Errorf("expected d with priority 5, got %q, %v, %v", c, p, err)
+	}
+	if got, want := drain(t, q), []string{"c", "a", "d"}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if _, _, err := q.RemoveLast(); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+	if _, _, err := q.PeekLast(); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+	q.Close()
+	if _, _, err := q.RemoveLast(); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+}
+
+func TestUpdatePriorityWhere(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		tp   Queuetype
+		want []string
+	}{
+		{PriorityHigh, []string{"x1", "x2", "x3", "b", "a"}},
+		{Fifo, []string{"x1", "a", "x2", "b", "x3"}},
+	} {
+		q, err := NewQueue[string](c.tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, content := range []string{"x1", "a", "x2", "b", "x3"} {
+			if err := q.Insert(NewPriorityElement(content, float64(i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		hooked := 0
+		q.OnInsert(func(Element[string]) { hooked++ })
+
+		// "x3" already has a priority above 3 and is not bumped.
+		n := q.UpdatePriorityWhere(func(content string, priority float64) bool {
+			return content[0] == 'x' && priority < 3
+		}, 5)
+		if n != 2 || hooked != 0 {
+			t.Errorf("queuetype %v: expected 2 updates without hooks, got %d and %d hooks", c.tp, n, hooked)
+		}
+		if got := drain(t, q); !equalContents(got, c.want) {
+			t.Errorf("queuetype %v: expected %v, got %v", c.tp, c.want, got)
+		}
+	}
+}
@@ -8,18 +8,99 @@ import (
 
 func (q *Queue[T]) remove(i int) (Element[T], error) {
 	elem, err := q.deleteWithoutMemoryManagement(i)
+	if err != nil {
+		return nil, errors.Wrap(err, "removing element")
+	}
+	q.left(elem)
+	q.handleShrink()
+	q.counters.removes.Add(1)
+	return elem, nil
+}
+
+// evict removes the element at i like remove, but counts it as an eviction.
+func (q *Queue[T]) evict(i int) (Element[T], error) {
+	elem, err := q.deleteWithoutMemoryManagement(i)
+	if err != nil {
+		return nil, errors.Wrap(err, "evicting element")
+	}
+	q.left(elem)
+	q.handleShrink()
+	q.counters.evictions.Add(1)
+	return elem, nil
+}
+
+// removeHeads removes the m elements that are removed first and returns them in removal order.
+// The backing slice is shrunk at most once. m must not exceed the number of elements.
+// The caller counts the removed elements in the metrics.
+func (q *Queue[T]) removeHeads(m int) []Element[T] {
+	rest := q.numElements - m
+	removed := make([]Element[T], m)
+	for i := range removed {
+		removed[i] = q.queueSlice[q.numElements-1-i]
+		q.left(removed[i])
+	}
+	if !q.skipGCNil {
+		clear(q.queueSlice[rest:])
+	}
+	q.queueSlice = q.queueSlice[:rest]
+	q.numElements = rest
+	q.notifyRemoved()
 	q.handleShrink()
-	return elem, errors.Wrap(err, "removing element")
+	return removed
 }
 
+// handleShrink reallocates the backing slice once too many of its slots are free.
+// Fifo queues keep up to as many free slots as they hold elements for their insertions, see
+// reserveFront, so only half of their backing slice counts. They are reallocated with the same
+// proportion of free slots in front of the elements.
+// The backing slice is not shrunk below the initial capacity, or at all with WithNoShrink. A
+// ShrinkPolicy replaces the heuristic of shrinkFactor and afterShrinkFactor.
 func (q *Queue[T]) handleShrink() {
+	if q.noShrink {
+		return
+	}
 	lenQ := len(q.queueSlice)
-	if float64(lenQ) < q.shrinkFactor()*float64(cap(q.queueSlice)) {
-		newCap := int(math.Ceil(q.afterShrinkFactor() * float64(cap(q.queueSlice))))
-		temp := make([]Element[T], lenQ, newCap)
-		copy(temp, q.queueSlice[:lenQ])
-		q.queueSlice = temp
+	capQ := cap(q.queueSlice)
+	if q.sharesFifoBuf() {
+		capQ = cap(q.fifoBuf) / 2
 	}
+
+	var newCap int
+	if q.shrinkPolicy != nil {
+		shrink, c := q.shrinkPolicy(lenQ, capQ)
+		if !shrink {
+			return
+		}
+		newCap = max(c, lenQ, q.initialCap)
+	} else {
+		if capQ <= q.initialCap || float64(lenQ) >= q.shrinkFactor()*float64(capQ) {
+			return
+		}
+		newCap = max(int(math.Ceil(q.afterShrinkFactor()*float64(capQ))), q.initialCap)
+	}
+	if newCap >= capQ {
+		return
+	}
+	q.reallocate(newCap)
+	q.counters.shrinks.Add(1)
+}
+
+// reallocate moves the elements to a new backing slice with room for newCap elements, which must
+// be at least q.Len(). Fifo queues get as many free slots in front of the elements.
+// Does not lock q.
+func (q *Queue[T]) reallocate(newCap int) {
+	lenQ := len(q.queueSlice)
+	if q.sharesFifoBuf() {
+		buf := make([]Element[T], 2*newCap)
+		start := len(buf) - lenQ
+		copy(buf[start:], q.queueSlice)
+		q.fifoBuf = buf
+		q.queueSlice = buf[start:]
+		return
+	}
+	temp := make([]Element[T], lenQ, newCap)
+	copy(temp, q.queueSlice[:lenQ])
+	q.queueSlice = temp
 }
 
 func (q *Queue[T]) deleteWithoutMemoryManagement(i int) (Element[T], error) {
@@ -28,21 +109,64 @@ func (q *Queue[T]) deleteWithoutMemoryManagement(i int) (Element[T], error) {
 		return nil, ErrEmptyQueue
 	}
 	if i < 0 || i >= q.numElements {
-		return nil, ErrIndexOutOfBounds
+		return nil, &IndexError{Index: i, Len: q.numElements}
 	}
 
 	elem := q.queueSlice[i]
-	if i == lenQ {
+	if i == lenQ-1 {
+		if !q.skipGCNil {
+			q.queueSlice[i] = nil
+		}
 		q.queueSlice = q.queueSlice[:i]
 	} else if i == 0 {
-		q.queueSlice[0] = nil
+		if !q.skipGCNil {
+			q.queueSlice[0] = nil
+		}
 		q.queueSlice = q.queueSlice[1:]
 	} else {
 		copy(q.queueSlice[i:], q.queueSlice[i+1:])
-		q.queueSlice[lenQ-1] = nil
+		if !q.skipGCNil {
+			q.queueSlice[lenQ-1] = nil
+		}
 		q.queueSlice = q.queueSlice[:lenQ-1]
 	}
 	q.numElements--
+	q.notifyRemoved()
 
 	return elem, nil
 }
+
+// removeWhere removes all elements for which remove returns true in one pass, keeping the
+// remaining elements in their order. Returns the removed elements in removal order.
+// The caller counts the removed elements in the metrics.
+func (q *Queue[T]) removeWhere(remove func(Element[T]) bool) []Element[T] {
+	var removed []Element[T]
+	kept := q.queueSlice[:0]
+	for _, elem := range q.queueSlice {
+		if remove(elem) {
+			removed = append(removed, elem)
+			q.left(elem)
+			continue
+		}
+		kept = append(kept, elem)
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if !q.skipGCNil {
+		for i := len(kept); i < len(q.queueSlice); i++ {
+			q.queueSlice[i] = nil
+		}
+	}
+	q.queueSlice = kept
+	q.numElements = len(kept)
+	q.notifyRemoved()
+	q.handleShrink()
+
+	// the end of the slice is removed first.
+	for i, j := 0, len(removed)-1; i < j; i, j = i+1, j-1 {
+		removed[i], removed[j] = removed[j], removed[i]
+	}
+	return removed
+}
@@ -0,0 +1,188 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRemoveGCNilOnRemove(t *testing.T) {
+	t.Parallel()
+	for _, enabled := range []bool{true, false} {
+		for _, tp := range []Queuetype{Fifo, Lifo} {
+			q, err := NewQueue[int](tp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q.SetGCNilOnRemove(enabled)
+
+			for i := 0; i < 100; i++ {
+				if err := q.Insert(NewBaseElement(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i := 0; i < 100; i++ {
+				want := i
+				if tp == Lifo {
+					want = 99 - i
+				}
+				got, _, err := q.Remove()
+				if err != nil {
+					t.Fatalf("nil on remove %v, queuetype %v: %v", enabled, tp, err)
+				}
+				if got != want {
+					t.Errorf("nil on remove %v, queuetype %v: expected %d, got %d", enabled, tp, want, got)
+				}
+			}
+
+			if _, _, err := q.Remove(); !errors.Is(err, ErrEmptyQueue) {
+				t.Errorf("nil on remove %v, queuetype %v: expected %v, got %v", enabled, tp, ErrEmptyQueue, err)
+			}
+		}
+	}
+}
+
+func TestDeleteGCNilOnRemove(t *testing.T) {
+	t.Parallel()
+	// index of the deleted element and the slot of the backing array it leaves behind.
+	cases := []struct {
+		name       string
+		index      int
+		vacantSlot int
+		want       []int
+	}{
+		{"head", 0, 0, []int{1, 2, 3, 4}},
+		{"middle", 2, 4, []int{0, 1, 3, 4}},
+		{"tail", 4, 4, []int{0, 1, 2, 3}},
+	}
+
+	for _, c := range cases {
+		for _, enabled := range []bool{true, false} {
+			q, err := NewQueue[int](Lifo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q.SetGCNilOnRemove(enabled)
+			for i := 0; i < 5; i++ {
+				q.Append(NewBaseElement(i))
+			}
+
+			backing := q.queueSlice
+			elem, err := q.deleteWithoutMemoryManagement(c.index)
+			if err != nil {
+				t.Fatalf("%s, nil on remove %v: %v", c.name, enabled, err)
+			}
+			if elem.Content() != c.index {
+				t.Errorf("%s, nil on remove %v: expected %d, got %d", c.name, enabled, c.index, elem.Content())
+			}
+
+			if enabled != (backing[c.vacantSlot] == nil) {
+				t.Errorf("%s, nil on remove %v: vacant slot is %v", c.name, enabled, backing[c.vacantSlot])
+			}
+
+			if q.Len() != len(c.want) {
+				t.Fatalf("%s, nil on remove %v: expected length %d, got %d", c.name, enabled, len(c.want), q.Len())
+			}
+			for i, e := range q.queueSlice {
+				if e.Content() != c.want[i] {
+					t.Errorf("%s, nil on remove %v: expected %v at %d, got %d", c.name, enabled, c.want[i], i, e.Content())
+				}
+			}
+		}
+	}
+}
+
+// benchmarkDelete measures deleteWithoutMemoryManagement directly, so that the reallocations of
+// handleShrink don't hide the cost of the nil-out. The queue is refilled outside of the timed
+// region whenever it runs empty.
+func benchmarkDelete(b *testing.B, nilOnRemove bool) {
+	const size = 1 << 16
+	q, _ := NewQueue[int](Lifo)
+	q.SetGCNilOnRemove(nilOnRemove)
+	elems := make([]Element[int], size)
+	for i := range elems {
+		elems[i] = NewBaseElement(i)
+	}
+	backing := make([]Element[int], size)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if q.numElements == 0 {
+			b.StopTimer()
+			copy(backing, elems)
+			q.queueSlice = backing
+			q.numElements = size
+			b.StartTimer()
+		}
+		_, _ = q.deleteWithoutMemoryManagement(q.numElements - 1)
+	}
+}
+
+func BenchmarkRemoveGCNilOnRemove(b *testing.B) {
+	benchmarkDelete(b, true)
+}
+
+func BenchmarkRemoveNoGCNilOnRemove(b *testing.B) {
+	benchmarkDelete(b, false)
+}
+
+func TestRemoveAt(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := q.RemoveAt(0); !errors.Is(err, ErrEmptyQueue) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrEmptyQueue, err)
+		}
+		for i := 0; i < 5; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64(i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, want, _ := q.PeekElemAtIndex(3)
+		got, _, err := q.RemoveAt(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("queuetype %v: expected %d, got %d", tp, want, got)
+		}
+		if _, _, err := q.RemoveAt(4); !errors.Is(err, ErrIndexOutOfBounds) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrIndexOutOfBounds, err)
+		}
+		if q.Len() != 4 {
+			t.Errorf("queuetype %v: expected length 4, got %d", tp, q.Len())
+		}
+	}
+}
+
+func TestRemoveWhere(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.RemoveWhere(func(int) bool { return true }); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := q.Insert(NewPriorityElement(i, float64(10-i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := q.RemoveWhere(func(c int) bool { return c%3 == 0 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || q.Metrics().Removes != 4 {
+		t.Errorf("expected 4 removals, got %d, counted %d", n, q.Metrics().Removes)
+	}
+	if got, want := drain(t, q), []int{8, 7, 5, 4, 2, 1}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -1,5 +1,57 @@
 package queue
 
+import "slices"
+
+// Compact reallocates the backing slice so that its capacity equals the length of the queue,
+// regardless of WithNoShrink and the initial capacity, like CloneCompact does for a clone.
+// Fifo queues make room for their insertions again on the next insertion.
+// Locks q.
+func (q *Queue[T]) Compact() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	capBefore := q.backingCap()
+	temp := make([]Element[T], q.numElements)
+	copy(temp, q.queueSlice)
+	q.queueSlice = temp
+	q.fifoBuf = nil
+	if q.backingCap() < capBefore {
+		q.counters.shrinks.Add(1)
+	}
+}
+
+// Grow makes room for at least n more elements, so that the next n insertions don't reallocate
+// the backing slice. Removals may shrink it again unless WithNoShrink or a ShrinkPolicy prevent
+// it.
+// Locks q. Returns ErrInvalidQueueLimit if n < 0.
+func (q *Queue[T]) Grow(n int) error {
+	if n < 0 {
+		return ErrInvalidQueueLimit
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	capBefore := q.backingCap()
+	if q.order != Fifo {
+		q.queueSlice = slices.Grow(q.queueSlice, n)
+		q.countGrow(capBefore)
+		return nil
+	}
+	// Fifo queues insert in front of their elements.
+	front := 0
+	if q.sharesFifoBuf() {
+		front = cap(q.fifoBuf) - cap(q.queueSlice)
+	}
+	if front < n {
+		buf := make([]Element[T], n+len(q.queueSlice))
+		copy(buf[n:], q.queueSlice)
+		q.fifoBuf = buf
+		q.queueSlice = buf[n:]
+	}
+	q.countGrow(capBefore)
+	return nil
+}
+
 // shrinkFactor determines a factor dynamically depending on the amount of elements in the queue
 // at what point to initiate a shrink operation on the underlying slice
 func (q *Queue[T]) shrinkFactor() float64 {
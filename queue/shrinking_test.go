@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestCompact(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityHigh} {
+		q, err := NewQueueWithOptions[int](tp, WithNoShrink())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 100; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64(i%3))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < 90; i++ {
+			if _, _, err := q.Remove(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ref := q.Clone()
+		if err := ref.Insert(NewPriorityElement(-1, -1)); err != nil {
+			t.Fatal(err)
+		}
+		want := drain(t, ref)
+
+		q.Compact()
+		if q.Capacity() != q.Len() || q.Metrics().Shrinks != 1 {
+			t.Errorf("queuetype %v: expected capacity %d after one shrink, got %d after %d",
+				tp, q.Len(), q.Capacity(), q.Metrics().Shrinks)
+		}
+		if err := q.Insert(NewPriorityElement(-1, -1)); err != nil {
+			t.Fatal(err)
+		}
+		if got := drain(t, q); !equalContents(got, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, got)
+		}
+	}
+}
+
+func TestGrow(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, Lifo, PriorityLow} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := q.Insert(NewPriorityElement(0, 0)); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.Grow(-1); !errors.Is(err, ErrInvalidQueueLimit) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrInvalidQueueLimit, err)
+		}
+		if err := q.Grow(500); err != nil {
+			t.Fatal(err)
+		}
+
+		grows := q.Metrics().Grows
+		for i := 1; i <= 500; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64(i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if q.Metrics().Grows != grows {
+			t.Errorf("queuetype %v: expected no grows after Grow, got %d", tp, q.Metrics().Grows-grows)
+		}
+		if q.Len() != 501 {
+			t.Errorf("queuetype %v: expected length 501, got %d", tp, q.Len())
+		}
+	}
+}
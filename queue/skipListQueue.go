@@ -0,0 +1,192 @@
+package queue
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// skipListMaxLevel bounds the height of the towers of a SkipListQueue. With
+	// skipListLevelChance = 1/4 it suffices for 4^32 elements.
+	skipListMaxLevel = 32
+
+	// skipListLevelChance is the probability for a tower to grow one level higher.
+	skipListLevelChance = 0.25
+)
+
+// SkipListQueue is a priority queue backed by a skip list instead of a slice. Insert and Remove run
+// in O(log n) expected time and the elements can be scanned in removal order without copying or
+// sorting them, also from a given priority on.
+// Like for Queue, elements with the same priority are removed oldest first.
+type SkipListQueue[T any] struct {
+	order Queuetype
+	lock  sync.Mutex
+	head  skipListNode[T]
+	level int
+	len   int
+	rnd   *rand.Rand
+}
+
+type skipListNode[T any] struct {
+	elem Element[T]
+	next []*skipListNode[T]
+}
+
+// NewSkipListQueue builds a new, empty SkipListQueue. Only PriorityHigh and PriorityLow are
+// supported, for all other Queuetypes ErrInvalidQueueType is returned.
+func NewSkipListQueue[T any](tp Queuetype) (*SkipListQueue[T], error) {
+	if tp != PriorityHigh && tp != PriorityLow {
+		return nil, ErrInvalidQueueType
+	}
+	return &SkipListQueue[T]{
+		order: tp,
+		head:  skipListNode[T]{next: make([]*skipListNode[T], skipListMaxLevel)},
+		level: 1,
+		rnd:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Len returns the number of elements in the queue.
+func (s *SkipListQueue[T]) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.len
+}
+
+// before reports whether an element with priority a is removed before one with priority b.
+func (s *SkipListQueue[T]) before(a, b float64) bool {
+	if s.order == PriorityHigh {
+		return a > b
+	}
+	return a < b
+}
+
+// randomLevel returns the height of the tower of a new node.
+func (s *SkipListQueue[T]) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && s.rnd.Float64() < skipListLevelChance {
+		level++
+	}
+	return level
+}
+
+// Insert inserts the passed element according to its priority in O(log n) expected time.
+// When there are multiple elements with the same priority the oldest elem will be the first that is
+// removed.
+func (s *SkipListQueue[T]) Insert(elem Element[T]) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// update[i] is the last node on level i that is removed before elem.
+	var update [skipListMaxLevel]*skipListNode[T]
+	n := &s.head
+	for i := s.level - 1; i >= 0; i-- {
+		// equal priorities are passed, so that elem is placed after the older elements.
+		for n.next[i] != nil && !s.before(elem.Priority(), n.next[i].elem.Priority()) {
+			n = n.next[i]
+		}
+		update[i] = n
+	}
+
+	level := s.randomLevel()
+	for ; s.level < level; s.level++ {
+		update[s.level] = &s.head
+	}
+	node := &skipListNode[T]{elem: elem, next: make([]*skipListNode[T], level)}
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	s.len++
+	return nil
+}
+
+// Remove pops the element that is meant to be removed first according to the queues order in
+// O(1) expected time.
+// Returns the Element split up into its pieces.
+// If the list is empty, an error is returned.
+func (s *SkipListQueue[T]) Remove() (T, float64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	first := s.head.next[0]
+	if first == nil {
+		return *new(T), 0, ErrEmptyQueue
+	}
+	for i := range first.next {
+		s.head.next[i] = first.next[i]
+	}
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+	s.len--
+	return first.elem.Content(), first.elem.Priority(), nil
+}
+
+// PeekElem returns a copy of the elem that would be returned on a call to Remove().
+// Returns an error of type ErrEmptyQueue when the list is empty.
+func (s *SkipListQueue[T]) PeekElem() (float64, T, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	first := s.head.next[0]
+	if first == nil {
+		return 0, *new(T), ErrEmptyQueue
+	}
+	return first.elem.Priority(), first.elem.Content(), nil
+}
+
+// Range calls f for every element in removal order until f returns false.
+// The queue is locked while Range runs, so f must not call methods of the queue.
+func (s *SkipListQueue[T]) Range(f func(priority float64, content T) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.rangeFrom(s.head.next[0], f)
+}
+
+// RangeFrom calls f like Range, but starts at the first element in removal order that is not
+// removed before an element with the passed priority, e.g. at the first element with a priority
+// <= priority for PriorityHigh queues. Finding that element takes O(log n) expected time.
+// The queue is locked while RangeFrom runs, so f must not call methods of the queue.
+func (s *SkipListQueue[T]) RangeFrom(priority float64, f func(priority float64, content T) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n := &s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for n.next[i] != nil && s.before(n.next[i].elem.Priority(), priority) {
+			n = n.next[i]
+		}
+	}
+	s.rangeFrom(n.next[0], f)
+}
+
+// rangeFrom calls f for n and all following nodes until f returns false.
+// Does not lock s.
+func (s *SkipListQueue[T]) rangeFrom(n *skipListNode[T], f func(priority float64, content T) bool) {
+	for ; n != nil; n = n.next[0] {
+		if !f(n.elem.Priority(), n.elem.Content()) {
+			return
+		}
+	}
+}
+
+// Iterator returns a channel which streams all elements of the queue in removal order.
+// The amount of items cached in the channel can be determined by channelCapacity.
+// The iterator can be stopped prematurely with the returned cancel function.
+// The contents are snapshotted under lock when Iterator is called.
+func (s *SkipListQueue[T]) Iterator(channelCapacity int) (<-chan T, context.CancelFunc) {
+	s.lock.Lock()
+	contents := make([]T, 0, s.len)
+	s.rangeFrom(s.head.next[0], func(_ float64, content T) bool {
+		contents = append(contents, content)
+		return true
+	})
+	s.lock.Unlock()
+
+	return streamContents(contents, channelCapacity)
+}
@@ -0,0 +1,169 @@
+package queue
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestSkipListQueueOrder(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{PriorityHigh, PriorityLow} {
+		s, err := NewSkipListQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reference, _ := NewQueue[int](tp)
+		for _, e := range randomPriorityElements(2000) {
+			if err := s.Insert(NewPriorityElement(e.Content(), e.Priority())); err != nil {
+				t.Fatal(err)
+			}
+			_ = reference.Insert(e)
+		}
+		if s.Len() != 2000 {
+			t.Fatalf("queuetype %v: expected length 2000, got %d", tp, s.Len())
+		}
+
+		want := viewContents(t, reference.Snapshot())
+		var ranged []int
+		s.Range(func(_ float64, c int) bool {
+			ranged = append(ranged, c)
+			return true
+		})
+		if !slices.Equal(ranged, want) {
+			t.Errorf("queuetype %v: Range does not match the removal order of Queue", tp)
+		}
+
+		ch, cancel := s.Iterator(16)
+		var iterated []int
+		for c := range ch {
+			iterated = append(iterated, c)
+		}
+		cancel()
+		if !slices.Equal(iterated, want) {
+			t.Errorf("queuetype %v: Iterator does not match the removal order of Queue", tp)
+		}
+
+		for i, w := range want {
+			_, c, err := s.PeekElem()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := s.Remove()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != w || c != w {
+				t.Fatalf("queuetype %v: expected %d at %d, got %d, peeked %d", tp, w, i, got, c)
+			}
+		}
+		if _, _, err := s.Remove(); !errors.Is(err, ErrEmptyQueue) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrEmptyQueue, err)
+		}
+	}
+}
+
+// viewContents returns the contents of v in removal order.
+func viewContents[T any](t *testing.T, v QueueView[T]) []T {
+	t.Helper()
+	var ret []T
+	v.Range(func(_ int, _ float64, c T) bool {
+		ret = append(ret, c)
+		return true
+	})
+	return ret
+}
+
+func TestSkipListQueueRangeFrom(t *testing.T) {
+	t.Parallel()
+	s, err := NewSkipListQueue[string](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []struct {
+		c string
+		p float64
+	}{{"a", 5}, {"b", 3}, {"c", 4}, {"d", 3}, {"e", 1}} {
+		_ = s.Insert(NewPriorityElement(e.c, e.p))
+	}
+
+	for _, c := range []struct {
+		from float64
+		want []string
+	}{
+		{10, []string{"a", "c", "b", "d", "e"}},
+		{3.5, []string{"b", "d", "e"}},
+		{3, []string{"b", "d", "e"}},
+		{0, nil},
+	} {
+		var got []string
+		s.RangeFrom(c.from, func(_ float64, content string) bool {
+			got = append(got, content)
+			return true
+		})
+		if !slices.Equal(got, c.want) {
+			t.Errorf("from %v: expected %v, got %v", c.from, c.want, got)
+		}
+	}
+
+	var got []string
+	s.RangeFrom(4, func(_ float64, content string) bool {
+		got = append(got, content)
+		return len(got) < 2
+	})
+	if want := []string{"c", "b"}; !slices.Equal(got, want) {
+		t.Errorf("expected the scan to stop after %v, got %v", want, got)
+	}
+
+	if _, err := NewSkipListQueue[int](Fifo); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+}
+
+// The mixed workloads insert random priorities into a queue of 10000 elements and scan the 100
+// elements that are removed first after every 16 inserts.
+const (
+	mixedWorkloadSize     = 10000
+	mixedWorkloadScanLen  = 100
+	mixedWorkloadScanRate = 16
+)
+
+func BenchmarkSkipListQueueMixed(b *testing.B) {
+	r := rand.New(rand.NewSource(42))
+	s, _ := NewSkipListQueue[int](PriorityHigh)
+	for i := 0; i < mixedWorkloadSize; i++ {
+		_ = s.Insert(NewPriorityElement(i, r.Float64()))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = s.Insert(NewPriorityElement(i, r.Float64()))
+		if i%mixedWorkloadScanRate == 0 {
+			n := 0
+			s.Range(func(float64, int) bool {
+				n++
+				return n < mixedWorkloadScanLen
+			})
+		}
+	}
+}
+
+func BenchmarkSliceQueueMixed(b *testing.B) {
+	r := rand.New(rand.NewSource(42))
+	q, _ := NewQueue[int](PriorityHigh)
+	for i := 0; i < mixedWorkloadSize; i++ {
+		_ = q.Insert(NewPriorityElement(i, r.Float64()))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = q.Insert(NewPriorityElement(i, r.Float64()))
+		if i%mixedWorkloadScanRate == 0 {
+			for j := 0; j < mixedWorkloadScanLen; j++ {
+				_, _, _ = q.PeekElemAtIndex(j)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// StreamTopK reads integers from r and returns the k largest (largest = true) or k smallest
+// (largest = false) of them, ordered from the most extreme value on. The values are kept in a
+// priority queue that never holds more than k+1 elements, so the stream can be arbitrarily long.
+//
+// decode is called with the unconsumed buffered bytes of the stream and returns the decoded value
+// and the number of bytes it consumed. When the bytes don't hold a complete value yet it must
+// return 0 consumed bytes and a nil error, and it is called again once more bytes were read.
+// Returns an error of type ErrInvalidQueueLimit if k < 1 and an error of type ErrTrailingInput if
+// the stream ends inside a value.
+func StreamTopK(r io.Reader, decode func([]byte) (int, int, error), k int, largest bool) ([]int, error) {
+	if k < 1 {
+		return nil, ErrInvalidQueueLimit
+	}
+
+	// the head of the queue is the least extreme kept value, which is evicted on overflow.
+	tp := PriorityHigh
+	if largest {
+		tp = PriorityLow
+	}
+	q, err := NewQueue[int](tp)
+	if err != nil {
+		return nil, errors.Wrap(err, "building queue")
+	}
+
+	buf := make([]byte, 0, 4096)
+	chunk := make([]byte, 4096)
+	for eof := false; !eof; {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if errors.Is(err, io.EOF) {
+			eof = true
+		} else if err != nil {
+			return nil, errors.Wrap(err, "reading stream")
+		}
+
+		consumed := 0
+		for consumed < len(buf) {
+			v, m, err := decode(buf[consumed:])
+			if err != nil {
+				return nil, errors.Wrap(err, "decoding value")
+			}
+			if m == 0 {
+				break
+			}
+			consumed += m
+
+			if err := q.Insert(NewPriorityElement(v, float64(v))); err != nil {
+				return nil, errors.Wrap(err, "inserting value")
+			}
+			if q.Len() > k {
+				if _, _, err := q.Remove(); err != nil {
+					return nil, errors.Wrap(err, "evicting value")
+				}
+			}
+		}
+		buf = append(buf[:0], buf[consumed:]...)
+	}
+	if len(buf) > 0 {
+		return nil, ErrTrailingInput
+	}
+
+	ret := make([]int, q.Len())
+	for i := len(ret) - 1; i >= 0; i-- {
+		v, _, err := q.Remove()
+		if err != nil {
+			return nil, errors.Wrap(err, "draining queue")
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
@@ -0,0 +1,83 @@
+package queue
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/pkg/errors"
+)
+
+// decodeLine decodes a newline terminated decimal integer.
+func decodeLine(b []byte) (int, int, error) {
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return 0, 0, nil
+	}
+	v, err := strconv.Atoi(string(b[:i]))
+	return v, i + 1, err
+}
+
+func TestStreamTopK(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(42))
+	values := make([]int, 10000)
+	var sb strings.Builder
+	for i := range values {
+		values[i] = r.Intn(2000) - 1000
+		sb.WriteString(strconv.Itoa(values[i]))
+		sb.WriteByte('\n')
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+
+	for _, k := range []int{1, 10, 500, 20000} {
+		for _, largest := range []bool{true, false} {
+			// OneByteReader splits values across reads.
+			got, err := StreamTopK(iotest.OneByteReader(strings.NewReader(sb.String())), decodeLine, k, largest)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			n := k
+			if n > len(sorted) {
+				n = len(sorted)
+			}
+			want := make([]int, n)
+			for i := range want {
+				if largest {
+					want[i] = sorted[len(sorted)-1-i]
+				} else {
+					want[i] = sorted[i]
+				}
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("k %d, largest %v: expected %d values, got %d", k, largest, len(want), len(got))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("k %d, largest %v: expected %v at %d, got %v", k, largest, want[i], i, got[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestStreamTopKErrors(t *testing.T) {
+	t.Parallel()
+	if _, err := StreamTopK(strings.NewReader("1\n"), decodeLine, 0, true); !errors.Is(err, ErrInvalidQueueLimit) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueLimit, err)
+	}
+	if _, err := StreamTopK(strings.NewReader("1\n2"), decodeLine, 1, true); !errors.Is(err, ErrTrailingInput) {
+		t.Errorf("expected %v, got %v", ErrTrailingInput, err)
+	}
+	if _, err := StreamTopK(strings.NewReader("x\n"), decodeLine, 1, true); err == nil {
+		t.Error("expected decode error")
+	}
+}
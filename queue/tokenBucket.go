@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TokenBucket is a rate limiter built on a FifoLimited queue. Every element of the queue is a token.
+// A token is added every refill interval until the bucket holds capacity tokens, so bursts of up to
+// capacity Takes are allowed.
+type TokenBucket struct {
+	tokens *Queue[struct{}]
+	cancel context.CancelFunc
+}
+
+// NewTokenBucket builds a new TokenBucket that starts full with capacity tokens and adds a token
+// every interval.
+// Returns an error of type ErrInvalidQueueLimit if capacity < 1.
+// Stop must be called to release the refill goroutine.
+func NewTokenBucket(capacity int, interval time.Duration) (*TokenBucket, error) {
+	return NewTokenBucketWithClock(capacity, interval, realClock{})
+}
+
+// NewTokenBucketWithClock builds a new TokenBucket like NewTokenBucket, but refills according to
+// the passed clock.
+func NewTokenBucketWithClock(capacity int, interval time.Duration, clock Clock) (*TokenBucket, error) {
+	if capacity < 1 {
+		return nil, ErrInvalidQueueLimit
+	}
+
+	tokens, err := NewQueue[struct{}](FifoLimited)
+	if err != nil {
+		return nil, errors.Wrap(err, "building token queue")
+	}
+	if err := tokens.SetLimit(capacity); err != nil {
+		return nil, errors.Wrap(err, "setting bucket capacity")
+	}
+	for i := 0; i < capacity; i++ {
+		if err := tokens.Insert(NewBaseElement(struct{}{})); err != nil {
+			return nil, errors.Wrap(err, "filling bucket")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &TokenBucket{
+		tokens: tokens,
+		cancel: cancel,
+	}
+	go b.refill(ctx, interval, clock)
+
+	return b, nil
+}
+
+// refill adds a token every interval until ctx is done. A full bucket evicts its oldest token, so
+// the amount of tokens never exceeds the capacity.
+func (b *TokenBucket) refill(ctx context.Context, interval time.Duration, clock Clock) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(interval):
+			_ = b.tokens.Insert(NewBaseElement(struct{}{}))
+		}
+	}
+}
+
+// Take takes a token from the bucket. If the bucket is empty it blocks until a token is refilled or
+// ctx is done.
+// Returns ctx.Err() if ctx is done before a token could be taken.
+func (b *TokenBucket) Take(ctx context.Context) error {
+	_, _, err := b.tokens.BlockingRemove(ctx)
+	return err
+}
+
+// Available returns the number of tokens currently in the bucket.
+func (b *TokenBucket) Available() int {
+	return b.tokens.Len()
+}
+
+// Stop stops the refilling of the bucket. Tokens that are still in the bucket can be taken.
+func (b *TokenBucket) Stop() {
+	b.cancel()
+}
@@ -0,0 +1,185 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fakeClock is a Clock that only advances when Advance is called.
+type fakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	c := &fakeClock{now: time.Unix(0, 0)}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	c.cond.Broadcast()
+	return ch
+}
+
+// Advance moves the clock forward by d and fires all waiters whose deadline has passed.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// BlockUntil blocks until n waiters are registered on the clock.
+func (c *fakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// waitFor polls cond until it is true or fails the test after a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func shortContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 10*time.Millisecond)
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	t.Parallel()
+	clock := newFakeClock()
+	b, err := NewTokenBucketWithClock(3, time.Second, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := shortContext()
+		if err := b.Take(ctx); err != nil {
+			t.Errorf("take %d: %v", i, err)
+		}
+		cancel()
+	}
+
+	ctx, cancel := shortContext()
+	defer cancel()
+	if err := b.Take(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestTokenBucketRefillRate(t *testing.T) {
+	t.Parallel()
+	clock := newFakeClock()
+	b, err := NewTokenBucketWithClock(2, time.Second, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+
+	for i := 0; i < 2; i++ {
+		if err := b.Take(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(500 * time.Millisecond)
+	if b.Available() != 0 {
+		t.Errorf("expected no refill before the interval, got %d tokens", b.Available())
+	}
+
+	for want := 1; want <= 4; want++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		exp := min(want, 2)
+		waitFor(t, func() bool { return b.Available() == exp })
+	}
+}
+
+func TestTokenBucketTakeBlocks(t *testing.T) {
+	t.Parallel()
+	clock := newFakeClock()
+	b, err := NewTokenBucketWithClock(1, time.Second, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+
+	if err := b.Take(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- b.Take(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("expected take to block, returned %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("take did not return after refill")
+	}
+}
+
+func TestNewTokenBucketInvalidCapacity(t *testing.T) {
+	t.Parallel()
+	if _, err := NewTokenBucket(0, time.Second); !errors.Is(err, ErrInvalidQueueLimit) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueLimit, err)
+	}
+}
@@ -0,0 +1,152 @@
+package queue
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// TopicQueue delivers published elements to the subscriptions of their topic, each of which holds
+// its own Queue of one Queuetype, so that every subscriber consumes all elements of its topics in
+// the order of the Queuetype and at its own pace.
+// Topics consist of segments separated by dots, like "orders.eu.created". The patterns of
+// subscriptions may use "*" for exactly one segment and "#" for any number of segments, so
+// "orders.*.created" and "orders.#" both match the topic above.
+type TopicQueue[T any] struct {
+	lock  sync.RWMutex
+	order Queuetype
+	subs  []*Subscription[T]
+}
+
+// Subscription is the subscription of a TopicQueue to the topics matching a pattern.
+type Subscription[T any] struct {
+	t       *TopicQueue[T]
+	pattern []string
+	q       *Queue[T]
+}
+
+// NewTopicQueue builds a TopicQueue whose subscriptions hold queues of Queuetype tp.
+// Returns ErrInvalidQueueType for nonexistent Queuetypes and Comparator.
+func NewTopicQueue[T any](tp Queuetype) (*TopicQueue[T], error) {
+	if _, err := NewQueue[T](tp); err != nil {
+		return nil, errors.Wrap(err, "building topic queue")
+	}
+	return &TopicQueue[T]{order: tp}, nil
+}
+
+// Subscribe subscribes to the topics matching pattern. The subscription receives the elements
+// published after it was created.
+// Returns ErrInvalidTopic if pattern has empty segments or wildcards within a segment.
+func (t *TopicQueue[T]) Subscribe(pattern string) (*Subscription[T], error) {
+	segments, err := splitTopic(pattern, true)
+	if err != nil {
+		return nil, err
+	}
+	q, err := NewQueue[T](t.order)
+	if err != nil {
+		return nil, errors.Wrap(err, "building subscription queue")
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := &Subscription[T]{t: t, pattern: segments, q: q}
+	t.subs = append(t.subs, s)
+	return s, nil
+}
+
+// Publish inserts elem into the queues of all subscriptions matching topic and returns to how
+// many of them it was delivered. Every subscription gets its own copy of elem, which shares the
+// content of elem.
+// Returns ErrInvalidTopic if topic has empty segments or wildcards and the first error of the
+// insertions, for example ErrQueueClosed if a subscription was closed in the meantime.
+func (t *TopicQueue[T]) Publish(topic string, elem Element[T]) (int, error) {
+	segments, err := splitTopic(topic, false)
+	if err != nil {
+		return 0, err
+	}
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	delivered := 0
+	var firstErr error
+	for _, s := range t.subs {
+		if !matchTopic(s.pattern, segments) {
+			continue
+		}
+		if err := s.q.Insert(copyElement(elem)); err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "delivering to subscription %q", strings.Join(s.pattern, "."))
+			}
+			continue
+		}
+		delivered++
+	}
+	return delivered, firstErr
+}
+
+// Queue returns the queue of s, from which the subscriber removes its elements.
+func (s *Subscription[T]) Queue() *Queue[T] {
+	return s.q
+}
+
+// Unsubscribe ends s, so that no more elements are delivered to it, and closes its queue like
+// Queue.Close. The elements already delivered can still be removed. Unsubscribing twice has no
+// effect.
+func (s *Subscription[T]) Unsubscribe() {
+	t := s.t
+	t.lock.Lock()
+	t.subs = slices.DeleteFunc(t.subs, func(other *Subscription[T]) bool { return other == s })
+	t.lock.Unlock()
+
+	s.q.Close()
+}
+
+// splitTopic splits a topic or, if wildcards is set, a pattern into its segments.
+// Returns ErrInvalidTopic for empty segments and for wildcards that are not allowed or not a whole
+// segment.
+func splitTopic(topic string, wildcards bool) ([]string, error) {
+	segments := strings.Split(topic, ".")
+	for _, seg := range segments {
+		switch {
+		case seg == "":
+			return nil, errors.Wrapf(ErrInvalidTopic, "empty segment in %q", topic)
+		case seg == "*" || seg == "#":
+			if !wildcards {
+				return nil, errors.Wrapf(ErrInvalidTopic, "wildcard in topic %q", topic)
+			}
+		case strings.ContainsAny(seg, "*#"):
+			return nil, errors.Wrapf(ErrInvalidTopic, "wildcard within segment %q", seg)
+		}
+	}
+	return segments, nil
+}
+
+// matchTopic reports whether the segments of topic match the segments of pattern.
+func matchTopic(pattern, topic []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case "#":
+			// "#" consumes any number of segments, including none.
+			for i := 0; i <= len(topic); i++ {
+				if matchTopic(pattern[1:], topic[i:]) {
+					return true
+				}
+			}
+			return false
+		case "*":
+		default:
+			if len(topic) == 0 || pattern[0] != topic[0] {
+				return false
+			}
+		}
+		if len(topic) == 0 {
+			return false
+		}
+		pattern, topic = pattern[1:], topic[1:]
+	}
+	return len(topic) == 0
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestTopicQueue(t *testing.T) {
+	t.Parallel()
+	tq, err := NewTopicQueue[string](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exact, _ := tq.Subscribe("orders.eu.created")
+	one, _ := tq.Subscribe("orders.*.created")
+	all, err := tq.Subscribe("orders.#")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []struct {
+		topic     string
+		content   string
+		priority  float64
+		delivered int
+	}{
+		{"orders.eu.created", "a", 1, 3},
+		{"orders.us.created", "b", 2, 2},
+		{"orders", "c", 3, 1},
+		{"orders.eu.created.late", "d", 4, 1},
+		{"invoices.eu.created", "e", 5, 0},
+	} {
+		n, err := tq.Publish(p.topic, NewPriorityElement(p.content, p.priority))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != p.delivered {
+			t.Errorf("topic %s: expected %d deliveries, got %d", p.topic, p.delivered, n)
+		}
+	}
+
+	for _, c := range []struct {
+		s    *Subscription[string]
+		want []string
+	}{
+		{exact, []string{"a"}},
+		{one, []string{"b", "a"}},
+		{all, []string{"d", "c", "b", "a"}},
+	} {
+		if got := drain(t, c.s.Queue()); !equalContents(got, c.want) {
+			t.Errorf("expected %v, got %v", c.want, got)
+		}
+	}
+
+	one.Unsubscribe()
+	one.Unsubscribe()
+	if n, _ := tq.Publish("orders.eu.created", NewPriorityElement("f", 0)); n != 2 {
+		t.Errorf("expected 2 deliveries after unsubscribing, got %d", n)
+	}
+	if _, _, err := one.Queue().Remove(); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+}
+
+func TestTopicQueueInvalidTopics(t *testing.T) {
+	t.Parallel()
+	tq, err := NewTopicQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pattern := range []string{"", "a..b", "a.b*", "#x"} {
+		if _, err := tq.Subscribe(pattern); !errors.Is(err, ErrInvalidTopic) {
+			t.Errorf("pattern %q: expected %v, got %v", pattern, ErrInvalidTopic, err)
+		}
+	}
+	for _, topic := range []string{"a.*", "#", "a."} {
+		if _, err := tq.Publish(topic, NewBaseElement(0)); !errors.Is(err, ErrInvalidTopic) {
+			t.Errorf("topic %q: expected %v, got %v", topic, ErrInvalidTopic, err)
+		}
+	}
+	if _, err := NewTopicQueue[int](Comparator); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+}
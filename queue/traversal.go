@@ -0,0 +1,59 @@
+package queue
+
+// BFS traverses the graph given by neighbors breadth-first, starting at start, and calls visit on
+// every reachable node exactly once. Nodes are visited in the order of their distance to start,
+// the neighbors of a node in the order neighbors returns them.
+// The traversal stops as soon as visit returns false.
+// It is backed by a Fifo queue.
+func BFS(start int, neighbors func(int) []int, visit func(int) bool) {
+	// a fresh Fifo queue is valid and never closed, so neither NewQueue nor Insert can fail.
+	q, _ := NewQueue[int](Fifo)
+	seen := map[int]struct{}{start: {}}
+	_ = q.Insert(NewBaseElement(start))
+
+	for q.Len() > 0 {
+		node, _, _ := q.Remove()
+		if !visit(node) {
+			return
+		}
+		for _, n := range neighbors(node) {
+			if _, ok := seen[n]; ok {
+				continue
+			}
+			seen[n] = struct{}{}
+			_ = q.Insert(NewBaseElement(n))
+		}
+	}
+}
+
+// DFS traverses the graph given by neighbors depth-first, starting at start, and calls visit on
+// every reachable node exactly once. Nodes are visited in the same order as a recursive preorder
+// traversal that follows the neighbors of a node in the order neighbors returns them.
+// The traversal stops as soon as visit returns false.
+// It is backed by a Lifo queue.
+func DFS(start int, neighbors func(int) []int, visit func(int) bool) {
+	// a fresh Lifo queue is valid and never closed, so neither NewQueue nor Insert can fail.
+	q, _ := NewQueue[int](Lifo)
+	visited := map[int]struct{}{}
+	_ = q.Insert(NewBaseElement(start))
+
+	for q.Len() > 0 {
+		node, _, _ := q.Remove()
+		// a node can be pushed several times before it is visited, only the first pop counts.
+		if _, ok := visited[node]; ok {
+			continue
+		}
+		visited[node] = struct{}{}
+		if !visit(node) {
+			return
+		}
+
+		ns := neighbors(node)
+		// pushed in reverse, so that the first neighbor is removed first.
+		for i := len(ns) - 1; i >= 0; i-- {
+			if _, ok := visited[ns[i]]; !ok {
+				_ = q.Insert(NewBaseElement(ns[i]))
+			}
+		}
+	}
+}
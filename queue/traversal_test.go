@@ -0,0 +1,80 @@
+package queue
+
+import "testing"
+
+// graph is a small directed graph with cycles:
+//
+//	0 -> 1, 2
+//	1 -> 3, 0
+//	2 -> 3, 4
+//	3 -> 5
+//	4 -> 2
+//	5 -> 1
+var graph = map[int][]int{
+	0: {1, 2},
+	1: {3, 0},
+	2: {3, 4},
+	3: {5},
+	4: {2},
+	5: {1},
+}
+
+func neighborsOf(n int) []int {
+	return graph[n]
+}
+
+func TestBFS(t *testing.T) {
+	t.Parallel()
+	var got []int
+	BFS(0, neighborsOf, func(n int) bool {
+		got = append(got, n)
+		return true
+	})
+
+	want := []int{0, 1, 2, 3, 4, 5}
+	if !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBFSEarlyStop(t *testing.T) {
+	t.Parallel()
+	var got []int
+	BFS(0, neighborsOf, func(n int) bool {
+		got = append(got, n)
+		return n != 2
+	})
+
+	want := []int{0, 1, 2}
+	if !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDFS(t *testing.T) {
+	t.Parallel()
+	var got []int
+	DFS(0, neighborsOf, func(n int) bool {
+		got = append(got, n)
+		return true
+	})
+
+	want := []int{0, 1, 3, 5, 2, 4}
+	if !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDFSEarlyStop(t *testing.T) {
+	t.Parallel()
+	var got []int
+	DFS(0, neighborsOf, func(n int) bool {
+		got = append(got, n)
+		return n != 5
+	})
+
+	want := []int{0, 1, 3, 5}
+	if !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
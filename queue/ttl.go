@@ -0,0 +1,128 @@
+package queue
+
+import "time"
+
+// ExpiringElement is a PriorityElement that expires at a point in time. Remove and PeekElem skip
+// expired elements at the head of the queue and drop them, PurgeExpired drops all of them.
+// Other operations see expired elements until they are dropped.
+type ExpiringElement[T any] struct {
+	PriorityElement[T]
+	expires time.Time
+}
+
+// Expires returns the point in time at which e expires.
+func (e ExpiringElement[T]) Expires() time.Time {
+	return e.expires
+}
+
+// expiring is implemented by elements that expire, like ExpiringElement.
+type expiring interface {
+	Expires() time.Time
+}
+
+// NewPriorityElementWithTTL builds a new Element with the passed content and priority that expires
+// after ttl has passed.
+func NewPriorityElementWithTTL[T any](c T, priority float64, ttl time.Duration) *ExpiringElement[T] {
+	return NewPriorityElementWithExpiry(c, priority, time.Now().Add(ttl))
+}
+
+// NewPriorityElementWithExpiry builds a new Element with the passed content and priority that
+// expires at expires.
+func NewPriorityElementWithExpiry[T any](c T, priority float64, expires time.Time) *ExpiringElement[T] {
+	return &ExpiringElement[T]{
+		PriorityElement: *NewPriorityElement(c, priority),
+		expires:         expires,
+	}
+}
+
+// SetClock sets the clock that decides whether elements are expired. A nil clock restores the
+// time package as the source of time.
+func (q *Queue[T]) SetClock(clock Clock) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.clock = clock
+}
+
+// PurgeExpired drops all expired elements in a single O(n) pass and returns their number.
+// Dropped elements count as expirations in the metrics.
+func (q *Queue[T]) PurgeExpired() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	now := q.now()
+	expired := q.removeWhere(func(elem Element[T]) bool {
+		return isExpired(elem, now)
+	})
+	q.counters.expirations.Add(uint64(len(expired)))
+	return len(expired)
+}
+
+// headExpired reports whether the head of the queue has expired, without dropping it.
+// Does not lock q.
+func (q *Queue[T]) headExpired() bool {
+	if q.numElements == 0 {
+		return false
+	}
+	head := q.queueSlice[q.numElements-1]
+	if _, ok := head.(expiring); !ok {
+		return false
+	}
+	return isExpired(head, q.now())
+}
+
+// dropExpiredHead drops the expired elements at the head of the queue, so that the head is alive.
+// Does not lock q.
+func (q *Queue[T]) dropExpiredHead() {
+	var now time.Time
+	dropped := false
+	for q.numElements > 0 {
+		head := q.queueSlice[q.numElements-1]
+		if _, ok := head.(expiring); !ok {
+			break
+		}
+		if now.IsZero() {
+			now = q.now()
+		}
+		if !isExpired(head, now) {
+			break
+		}
+		if _, err := q.deleteWithoutMemoryManagement(q.numElements - 1); err != nil {
+			break
+		}
+		q.left(head)
+		q.counters.expirations.Add(1)
+		dropped = true
+	}
+	if dropped {
+		q.handleShrink()
+	}
+}
+
+// dropExpired removes the expired elements from elems, which were removed from q, counts them as
+// expirations and the others as removals.
+// Does not lock q.
+func (q *Queue[T]) dropExpired(elems []Element[T]) []Element[T] {
+	var now time.Time
+	alive := elems[:0]
+	for _, elem := range elems {
+		if _, ok := elem.(expiring); ok {
+			if now.IsZero() {
+				now = q.now()
+			}
+			if isExpired(elem, now) {
+				q.counters.expirations.Add(1)
+				continue
+			}
+		}
+		alive = append(alive, elem)
+	}
+	q.counters.removes.Add(uint64(len(alive)))
+	return alive
+}
+
+// isExpired reports whether elem expires at or before now.
+func isExpired[T any](elem Element[T], now time.Time) bool {
+	e, ok := elem.(expiring)
+	return ok && !e.Expires().After(now)
+}
@@ -0,0 +1,99 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestExpiringElements(t *testing.T) {
+	t.Parallel()
+	clock := newFakeClock()
+	q, err := NewQueue[int](Fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.SetClock(clock)
+	start := clock.Now()
+	for i, ttl := range []time.Duration{time.Second, 3 * time.Second, 0, 2 * time.Second} {
+		var elem Element[int] = NewPriorityElementWithExpiry(i, 0, start.Add(ttl))
+		if ttl == 0 {
+			elem = NewBaseElement(i)
+		}
+		if err := q.Insert(elem); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock.Advance(time.Second)
+	_, c, err := q.PeekElem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != 1 {
+		t.Errorf("expected the expired head to be skipped, got %d", c)
+	}
+
+	clock.Advance(time.Second)
+	if n := q.PurgeExpired(); n != 1 {
+		t.Errorf("expected 1 purged element, got %d", n)
+	}
+	if got, want := drain(t, q), []int{1, 2}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	m := q.Metrics()
+	if m.Expirations != 2 || m.Removes != 2 {
+		t.Errorf("expected 2 expirations and 2 removes, got %d and %d", m.Expirations, m.Removes)
+	}
+}
+
+func TestExpiringElementsRemove(t *testing.T) {
+	t.Parallel()
+	clock := newFakeClock()
+	q, err := NewQueue[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.SetClock(clock)
+	for i := 0; i < 4; i++ {
+		if err := q.Insert(NewPriorityElementWithExpiry(i, float64(i), clock.Now().Add(time.Second))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Insert(NewPriorityElement(10, -1)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := q.RemoveBatch(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != 3 {
+		t.Errorf("expected [3], got %v", got)
+	}
+
+	clock.Advance(time.Second)
+	c, _, err := q.Remove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != 10 {
+		t.Errorf("expected the expired elements to be skipped, got %d", c)
+	}
+	if _, _, err := q.Remove(); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+}
+
+func TestNewPriorityElementWithTTL(t *testing.T) {
+	t.Parallel()
+	before := time.Now()
+	elem := NewPriorityElementWithTTL("a", 2, time.Minute)
+	if elem.Content() != "a" || elem.Priority() != 2 {
+		t.Errorf("expected (a, 2), got (%s, %v)", elem.Content(), elem.Priority())
+	}
+	if elem.Expires().Before(before.Add(time.Minute)) || elem.Expires().After(time.Now().Add(time.Minute)) {
+		t.Errorf("expected expiry in a minute, got %v", elem.Expires())
+	}
+}
@@ -0,0 +1,63 @@
+package queue
+
+import "sync"
+
+// queueLock is the lock of a Queue. The lock of a queue created with NewQueueUnsecure is disabled,
+// so that all its methods return immediately.
+type queueLock struct {
+	mu       sync.RWMutex
+	disabled bool
+
+	// beforeWrite is called once by the next Lock after it is set, since every mutation of the
+	// queue takes the write lock. See Queue.CloneCOW.
+	beforeWrite func()
+}
+
+func (l *queueLock) Lock() {
+	l.lockKeepingShared()
+	if f := l.beforeWrite; f != nil {
+		l.beforeWrite = nil
+		f()
+	}
+}
+
+// lockKeepingShared locks l like Lock without calling beforeWrite.
+func (l *queueLock) lockKeepingShared() {
+	if !l.disabled {
+		l.mu.Lock()
+	}
+}
+
+func (l *queueLock) Unlock() {
+	if !l.disabled {
+		l.mu.Unlock()
+	}
+}
+
+func (l *queueLock) RLock() {
+	if !l.disabled {
+		l.mu.RLock()
+	}
+}
+
+func (l *queueLock) RUnlock() {
+	if !l.disabled {
+		l.mu.RUnlock()
+	}
+}
+
+// NewQueueUnsecure creates a queue of Queuetype tp like NewQueue that never locks, for use by a
+// single goroutine, where the locking dominates the cost of operations on small queues. It is a
+// *Queue with the same API, so it can be swapped for a synchronized queue.
+// None of its methods must be called concurrently. Blocking methods like BlockingRemove can't be
+// woken by another goroutine and only return once their context is done. Clones, splits and
+// partitions of the queue don't lock either.
+// Returns ErrInvalidQueueType for nonexistent Queuetypes and Comparator.
+func NewQueueUnsecure[T any](tp Queuetype) (*Queue[T], error) {
+	q, err := NewQueue[T](tp)
+	if err != nil {
+		return nil, err
+	}
+	q.lock.disabled = true
+	return q, nil
+}
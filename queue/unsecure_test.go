@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestNewQueueUnsecure(t *testing.T) {
+	t.Parallel()
+	if _, err := NewQueueUnsecure[int](Comparator); !errors.Is(err, ErrInvalidQueueType) {
+		t.Errorf("expected %v, got %v", ErrInvalidQueueType, err)
+	}
+
+	q, err := NewQueueUnsecure[int](PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a held lock would block every call if the queue locked.
+	q.lock.Lock()
+	for i, p := range []float64{2, 3, 1} {
+		if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clone := q.Clone()
+	if got, want := drain(t, q), []int{1, 0, 2}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if !clone.lock.disabled {
+		t.Error("clone of an unsecure queue locks")
+	}
+}
+
+func benchmarkInsertRemove(b *testing.B, q *Queue[int]) {
+	for i := 0; i < b.N; i++ {
+		_ = q.Insert(NewPriorityElement(i, float64(i%8)))
+		if q.Len() > 16 {
+			_, _, _ = q.Remove()
+		}
+	}
+}
+
+func BenchmarkInsertRemoveLocked(b *testing.B) {
+	q, _ := NewQueue[int](PriorityHigh)
+	benchmarkInsertRemove(b, q)
+}
+
+func BenchmarkInsertRemoveUnsecure(b *testing.B) {
+	q, _ := NewQueueUnsecure[int](PriorityHigh)
+	benchmarkInsertRemove(b, q)
+}
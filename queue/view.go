@@ -0,0 +1,142 @@
+package queue
+
+import "time"
+
+// QueueView is an immutable snapshot of the elements of a queue, built by Queue.Snapshot.
+// It holds copies of the priorities and contents taken at snapshot time, so it can be shared between
+// goroutines without locking. Reference typed contents still point to the same data as the queue.
+// Indices are in removal order, like for PeekElemAtIndex.
+// The view remembers the elements, so that the queue it was taken of can be rolled back to it with
+// Restore.
+type QueueView[T any] struct {
+	entries []viewEntry[T]
+	source  *Queue[T]
+	order   Queuetype
+}
+
+type viewEntry[T any] struct {
+	elem        Element[T]
+	priority    float64
+	subPriority float64
+	content     T
+	seq         uint64
+	readyAt     time.Time
+}
+
+// Snapshot returns a QueueView of the current elements of the queue.
+// Locks q.
+func (q *Queue[T]) Snapshot() QueueView[T] {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return QueueView[T]{entries: q.snapshotEntries(), source: q, order: q.order}
+}
+
+// Restore rolls the queue back to the snapshot v of it, e.g. to undo the removals of a batch whose
+// processing failed. The elements of v get back their priorities, contents and insertion ages and
+// replace the current elements, so handles of elements in v are valid again. Elements that were
+// removed count as inserted again and elements that were inserted since the snapshot as removed,
+// also for the hooks. Restoring does not change the configuration of the queue, a FifoLimited or
+// LRU queue over its limit drops its oldest elements.
+// Returns ErrForeignSnapshot if v was not taken of q.
+// Locks q.
+func (q *Queue[T]) Restore(v QueueView[T]) error {
+	if v.source != q {
+		return ErrForeignSnapshot
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	current := make(map[Element[T]]struct{}, q.numElements)
+	for _, elem := range q.queueSlice {
+		current[elem] = struct{}{}
+	}
+
+	restored := make([]Element[T], len(v.entries))
+	for i, e := range v.entries {
+		elem := e.elem
+		elem.SetPriority(e.priority)
+		elem.SetContent(e.content)
+		if s, ok := elem.(*SubPriorityElement[T]); ok {
+			s.SetSubPriority(e.subPriority)
+		}
+		if s, ok := elem.(sequenced); ok {
+			s.setSequence(e.seq)
+		}
+		// the view is in removal order, the element removed first belongs to the end.
+		restored[len(restored)-1-i] = elem
+	}
+
+	inSnapshot := make(map[Element[T]]struct{}, len(restored))
+	for _, elem := range restored {
+		inSnapshot[elem] = struct{}{}
+		if _, ok := current[elem]; !ok {
+			q.hookInserted(elem)
+			q.counters.inserts.Add(1)
+		}
+	}
+	for _, elem := range q.queueSlice {
+		if _, ok := inSnapshot[elem]; !ok {
+			q.hookRemoved(elem)
+			q.counters.removes.Add(1)
+		}
+	}
+
+	q.queueSlice = restored
+	q.fifoBuf = nil
+	q.numElements = len(restored)
+	q.countLen()
+	q.reindex()
+	if q.order != v.order {
+		q.rebuildInvariant()
+	} else {
+		q.trimToLimit()
+	}
+	q.notifyInserted()
+	q.notifyRemoved()
+	return nil
+}
+
+// snapshotEntries copies the priorities, contents and sequence numbers of the elements of q in
+// removal order.
+// Does not lock q.
+func (q *Queue[T]) snapshotEntries() []viewEntry[T] {
+	entries := make([]viewEntry[T], q.numElements)
+	for i := range entries {
+		elem := q.queueSlice[q.numElements-1-i]
+		entries[i] = viewEntry[T]{
+			elem:        elem,
+			priority:    elem.Priority(),
+			subPriority: subPriorityOf(elem),
+			content:     elem.Content(),
+			seq:         sequenceOf(elem),
+			readyAt:     readyAtOf(elem),
+		}
+	}
+	return entries
+}
+
+// Len returns the number of elements in the view.
+func (v QueueView[T]) Len() int {
+	return len(v.entries)
+}
+
+// At returns the priority and content of the elem at index.
+// Returns an *IndexError, which matches ErrIndexOutOfBounds, when the provided index is out of
+// bounds.
+func (v QueueView[T]) At(index int) (float64, T, error) {
+	if index < 0 || index >= len(v.entries) {
+		return 0, *new(T), &IndexError{Index: index, Len: len(v.entries)}
+	}
+	e := v.entries[index]
+	return e.priority, e.content, nil
+}
+
+// Range calls f for every element of the view in removal order until f returns false.
+func (v QueueView[T]) Range(f func(index int, priority float64, content T) bool) {
+	for i, e := range v.entries {
+		if !f(i, e.priority, e.content) {
+			return
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+	q, err := NewQueue[int](PriorityLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range []float64{4, 2, 8, 2} {
+		if err := q.Insert(NewPriorityElement(i, p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	view := q.Snapshot()
+
+	if _, _, err := q.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Insert(NewPriorityElement(9, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.MapInPlace(func(c int) (int, error) { return c * 100, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	wantContents := []int{1, 3, 0, 2}
+	wantPriorities := []float64{2, 2, 4, 8}
+	if view.Len() != len(wantContents) {
+		t.Fatalf("expected length %d, got %d", len(wantContents), view.Len())
+	}
+	for i := range wantContents {
+		p, c, err := view.At(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != wantContents[i] || p != wantPriorities[i] {
+			t.Errorf("at %d: expected (%v, %d), got (%v, %d)", i, wantPriorities[i], wantContents[i], p, c)
+		}
+	}
+
+	var ranged []int
+	view.Range(func(i int, _ float64, c int) bool {
+		ranged = append(ranged, c)
+		return i < 2
+	})
+	if !equalContents(ranged, wantContents[:3]) {
+		t.Errorf("expected %v, got %v", wantContents[:3], ranged)
+	}
+
+	if _, _, err := view.At(4); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("expected %v, got %v", ErrIndexOutOfBounds, err)
+	}
+	if _, _, err := view.At(-1); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("expected %v, got %v", ErrIndexOutOfBounds, err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+	for _, tp := range []Queuetype{Fifo, PriorityHigh} {
+		q, err := NewQueue[int](tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			if err := q.Insert(NewPriorityElement(i, float64(i%2))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want := drain(t, q.Clone())
+		var removed, inserted int
+		q.OnRemove(func(Element[int]) { removed++ })
+		q.OnInsert(func(Element[int]) { inserted++ })
+
+		snap := q.Snapshot()
+		// a failed batch: two elements consumed, one produced and one reprioritized.
+		for i := 0; i < 2; i++ {
+			if _, _, err := q.Remove(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := q.Insert(NewPriorityElement(9, 9)); err != nil {
+			t.Fatal(err)
+		}
+		q.UpdatePriority(0, 5, false)
+		removed, inserted = 0, 0
+
+		if err := q.Restore(snap); err != nil {
+			t.Fatal(err)
+		}
+		if removed != 1 || inserted != 2 {
+			t.Errorf("queuetype %v: expected 1 removal and 2 insertions, got %d and %d", tp, removed, inserted)
+		}
+		if got := drain(t, q); !equalContents(got, want) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, want, got)
+		}
+
+		other, _ := NewQueue[int](tp)
+		if err := other.Restore(snap); !errors.Is(err, ErrForeignSnapshot) {
+			t.Errorf("queuetype %v: expected %v, got %v", tp, ErrForeignSnapshot, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// WeightedRandomQueue removes its elements at random, each with a probability proportional to its
+// priority, e.g. for stochastic schedulers or for shaping traffic between variants. The weights
+// are kept in a sum tree over the slots of the elements, so Insert and Remove take O(log n).
+// Elements with priority 0 are only removed once all remaining elements have priority 0, then
+// they are picked uniformly.
+type WeightedRandomQueue[T any] struct {
+	lock  sync.Mutex
+	rng   *rand.Rand
+	elems []Element[T]
+	// sums is the sum tree of the priorities of elems: the leaves start at len(sums)/2, every
+	// inner node i holds the sum of its children 2i and 2i+1, sums[1] the total.
+	sums []float64
+}
+
+// NewWeightedRandomQueue builds a new, empty WeightedRandomQueue that draws its random numbers
+// from rng, or from the global source of math/rand if rng is nil. rng is only used under the lock
+// of the queue.
+func NewWeightedRandomQueue[T any](rng *rand.Rand) *WeightedRandomQueue[T] {
+	return &WeightedRandomQueue[T]{rng: rng}
+}
+
+// Insert inserts elem with its priority as its weight in O(log n) amortized.
+// Returns ErrInvalidWeight if the priority is negative, infinite or NaN.
+func (w *WeightedRandomQueue[T]) Insert(elem Element[T]) error {
+	weight := elem.Priority()
+	if !(weight >= 0) || math.IsInf(weight, 1) {
+		return ErrInvalidWeight
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.elems) == len(w.sums)/2 {
+		w.grow()
+	}
+	w.elems = append(w.elems, elem)
+	w.setWeight(len(w.elems)-1, weight)
+	return nil
+}
+
+// grow doubles the number of leaves of the sum tree and rebuilds it.
+// Does not lock w.
+func (w *WeightedRandomQueue[T]) grow() {
+	// the tree has len(w.sums)/2 leaves.
+	leaves := max(len(w.sums), minFifoHeadroom)
+	w.sums = make([]float64, 2*leaves)
+	for i, elem := range w.elems {
+		w.sums[leaves+i] = elem.Priority()
+	}
+	for i := leaves - 1; i > 0; i-- {
+		w.sums[i] = w.sums[2*i] + w.sums[2*i+1]
+	}
+}
+
+// setWeight sets the weight of slot i and recomputes the sums above it. The sums are added up
+// again instead of adjusted by the difference, so that rounding errors don't accumulate.
+// Does not lock w.
+func (w *WeightedRandomQueue[T]) setWeight(i int, weight float64) {
+	node := len(w.sums)/2 + i
+	w.sums[node] = weight
+	for node > 1 {
+		node /= 2
+		w.sums[node] = w.sums[2*node] + w.sums[2*node+1]
+	}
+}
+
+// Remove removes a random element, each with a probability proportional to its priority, in
+// O(log n) and returns its content and priority.
+// If the queue is empty, ErrEmptyQueue is returned.
+func (w *WeightedRandomQueue[T]) Remove() (T, float64, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.elems) == 0 {
+		return *new(T), 0, ErrEmptyQueue
+	}
+	i := w.pick()
+	elem := w.elems[i]
+
+	// the last element takes over the slot, so that the slots stay contiguous.
+	last := len(w.elems) - 1
+	w.elems[i] = w.elems[last]
+	w.elems[last] = nil
+	w.elems = w.elems[:last]
+	if i < last {
+		w.setWeight(i, w.elems[i].Priority())
+	}
+	w.setWeight(last, 0)
+	return elem.Content(), elem.Priority(), nil
+}
+
+// pick returns the slot of a random element chosen by weight.
+// Does not lock w.
+func (w *WeightedRandomQueue[T]) pick() int {
+	if w.sums[1] == 0 {
+		return w.intn(len(w.elems))
+	}
+	r := w.float64() * w.sums[1]
+	node := 1
+	for node < len(w.sums)/2 {
+		left := 2 * node
+		// rounding can leave r at the total of the left subtree, a right subtree without weight
+		// must not be chosen because of it.
+		if r < w.sums[left] || w.sums[left+1] == 0 {
+			node = left
+		} else {
+			r -= w.sums[left]
+			node = left + 1
+		}
+	}
+	return node - len(w.sums)/2
+}
+
+func (w *WeightedRandomQueue[T]) float64() float64 {
+	if w.rng == nil {
+		return rand.Float64()
+	}
+	return w.rng.Float64()
+}
+
+func (w *WeightedRandomQueue[T]) intn(n int) int {
+	if w.rng == nil {
+		return rand.Intn(n)
+	}
+	return w.rng.Intn(n)
+}
+
+// Len returns the number of elements in the queue.
+func (w *WeightedRandomQueue[T]) Len() int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return len(w.elems)
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestWeightedRandomQueue(t *testing.T) {
+	t.Parallel()
+	rng := rand.New(rand.NewSource(42))
+	const trials = 4000
+	first := map[string]int{}
+	for i := 0; i < trials; i++ {
+		w := NewWeightedRandomQueue[string](rng)
+		for _, e := range []struct {
+			content string
+			weight  float64
+		}{{"a", 1}, {"b", 3}, {"c", 0}, {"d", 4}} {
+			if err := w.Insert(NewPriorityElement(e.content, e.weight)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		c, _, err := w.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		first[c]++
+	}
+
+	// a, b and d are removed first with probabilities 1/8, 3/8 and 4/8.
+	for c, want := range map[string]float64{"a": trials / 8, "b": 3 * trials / 8, "d": trials / 2} {
+		if got := float64(first[c]); math.Abs(got-want) > 0.1*want {
+			t.Errorf("expected %s to be removed first about %v times, got %v", c, want, got)
+		}
+	}
+	if first["c"] != 0 {
+		t.Errorf("expected the zero weight to never be removed first, got %d", first["c"])
+	}
+}
+
+func TestWeightedRandomQueueDrain(t *testing.T) {
+	t.Parallel()
+	w := NewWeightedRandomQueue[int](rand.New(rand.NewSource(1)))
+	for i := 0; i < 100; i++ {
+		if err := w.Insert(NewPriorityElement(i, float64(i%5))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, weight := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if err := w.Insert(NewPriorityElement(0, weight)); !errors.Is(err, ErrInvalidWeight) {
+			t.Errorf("weight %v: expected %v, got %v", weight, ErrInvalidWeight, err)
+		}
+	}
+
+	var got []int
+	for w.Len() > 0 {
+		c, p, err := w.Remove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != float64(c%5) {
+			t.Errorf("expected priority %d for %d, got %v", c%5, c, p)
+		}
+		got = append(got, c)
+	}
+	// the elements with weight 0 are removed last.
+	for _, c := range got[80:] {
+		if c%5 != 0 {
+			t.Errorf("expected only zero weights at the end, got %d", c)
+		}
+	}
+	slices.Sort(got)
+	for i, c := range got {
+		if c != i {
+			t.Fatalf("expected every element once, got %v", got)
+		}
+	}
+	if _, _, err := w.Remove(); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+}
@@ -0,0 +1,41 @@
+package queue
+
+import "github.com/pkg/errors"
+
+// WorkQueue distributes work items among concurrent consumers. It is built on a Fifo queue.
+// Every item that is added is claimed by exactly one consumer, in the order the items were added.
+// This is the counterpart to Broadcast, which delivers every element to every consumer.
+type WorkQueue[T any] struct {
+	items *Queue[T]
+}
+
+// NewWorkQueue builds a new, empty WorkQueue.
+func NewWorkQueue[T any]() *WorkQueue[T] {
+	// a Fifo queue is always valid.
+	items, _ := NewQueue[T](Fifo)
+	return &WorkQueue[T]{items: items}
+}
+
+// Add adds item to the end of the work queue.
+func (w *WorkQueue[T]) Add(item T) error {
+	if err := w.items.Insert(NewBaseElement(item)); err != nil {
+		return errors.Wrap(err, "adding work item")
+	}
+	return nil
+}
+
+// Claim removes the oldest item from the work queue and hands it to the caller. The removal is
+// atomic, so no other caller can claim the same item.
+// Returns false if there is no item to claim.
+func (w *WorkQueue[T]) Claim() (T, bool) {
+	item, _, err := w.items.Remove()
+	if err != nil {
+		return *new(T), false
+	}
+	return item, true
+}
+
+// Len returns the number of unclaimed items.
+func (w *WorkQueue[T]) Len() int {
+	return w.items.Len()
+}
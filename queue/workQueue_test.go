@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestWorkQueueClaimOnce(t *testing.T) {
+	t.Parallel()
+	const n = 5000
+	w := NewWorkQueue[int]()
+	for i := 0; i < n; i++ {
+		if err := w.Add(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	claimed := make([][]int, 8)
+	for c := range claimed {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			for {
+				item, ok := w.Claim()
+				if !ok {
+					return
+				}
+				claimed[c] = append(claimed[c], item)
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	seen := make([]int, n)
+	for c, items := range claimed {
+		for i, item := range items {
+			seen[item]++
+			// every consumer sees its items in the order they were added.
+			if i > 0 && items[i-1] > item {
+				t.Errorf("consumer %d: claimed %d after %d", c, item, items[i-1])
+			}
+		}
+	}
+	for item, count := range seen {
+		if count != 1 {
+			t.Errorf("item %d was claimed %d times", item, count)
+		}
+	}
+	if w.Len() != 0 {
+		t.Errorf("expected no unclaimed items, got %d", w.Len())
+	}
+}
+
+func TestWorkQueueEmpty(t *testing.T) {
+	t.Parallel()
+	w := NewWorkQueue[string]()
+	if _, ok := w.Claim(); ok {
+		t.Errorf("expected nothing to claim")
+	}
+	_ = w.Add("a")
+	if item, ok := w.Claim(); !ok || item != "a" {
+		t.Errorf("expected to claim a, got %q, %v", item, ok)
+	}
+}
@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// WorkStealingDeque is a lock-free deque for task schedulers after D. Chase and Y. Lev: a single
+// owner goroutine pushes and pops contents at the bottom, like a stack, while any number of thieves
+// steal the oldest contents from the top. The owner only competes with thieves for the last
+// content, so in the common case Push and Pop take no compare-and-swap.
+// The deque grows without bounds. Push and Pop must only be called by the owner.
+type WorkStealingDeque[T any] struct {
+	top atomic.Int64 // position of the next Steal
+	_   [cacheLineSize - 8]byte
+	// bottom is the position of the next Push. It is only written by the owner.
+	bottom atomic.Int64
+	_      [cacheLineSize - 8]byte
+
+	ring atomic.Pointer[dequeRing[T]]
+}
+
+// dequeRing is a circular array of a WorkStealingDeque. Its slots are atomic, since a thief may
+// read a slot while the owner reuses it, in which case the thief loses the compare-and-swap on top
+// and discards what it read.
+type dequeRing[T any] struct {
+	mask  int64
+	slots []atomic.Pointer[T]
+}
+
+func newDequeRing[T any](size int64) *dequeRing[T] {
+	return &dequeRing[T]{mask: size - 1, slots: make([]atomic.Pointer[T], size)}
+}
+
+func (r *dequeRing[T]) get(i int64) *T {
+	return r.slots[i&r.mask].Load()
+}
+
+func (r *dequeRing[T]) put(i int64, c *T) {
+	r.slots[i&r.mask].Store(c)
+}
+
+// grow returns a ring of twice the size holding the positions [top, bottom) of r. r stays intact,
+// so that thieves that still read it get the same contents.
+func (r *dequeRing[T]) grow(top, bottom int64) *dequeRing[T] {
+	grown := newDequeRing[T](2 * (r.mask + 1))
+	for i := top; i < bottom; i++ {
+		grown.put(i, r.get(i))
+	}
+	return grown
+}
+
+// NewWorkStealingDeque builds an empty WorkStealingDeque with room for capacity contents before it
+// grows the first time. The capacity is rounded up to the next power of two.
+// Returns ErrInvalidQueueLimit if capacity < 1.
+func NewWorkStealingDeque[T any](capacity int) (*WorkStealingDeque[T], error) {
+	if capacity < 1 || capacity > 1<<(bits.UintSize-2) {
+		return nil, ErrInvalidQueueLimit
+	}
+	d := &WorkStealingDeque[T]{}
+	d.ring.Store(newDequeRing[T](int64(1) << bits.Len64(uint64(capacity-1))))
+	return d, nil
+}
+
+// Push adds c at the bottom of the deque. Must only be called by the owner.
+func (d *WorkStealingDeque[T]) Push(c T) {
+	b := d.bottom.Load()
+	t := d.top.Load()
+	r := d.ring.Load()
+	if b-t > r.mask {
+		r = r.grow(t, b)
+		d.ring.Store(r)
+	}
+	r.put(b, &c)
+	d.bottom.Store(b + 1)
+}
+
+// Pop removes the content that was pushed last. Must only be called by the owner.
+// Returns ErrEmptyQueue if the deque is empty or a thief took the last content.
+func (d *WorkStealingDeque[T]) Pop() (T, error) {
+	b := d.bottom.Load() - 1
+	r := d.ring.Load()
+	// claim b before looking at top, so that a thief either sees the claim or the owner sees the
+	// thief's steal.
+	d.bottom.Store(b)
+	t := d.top.Load()
+	if t > b {
+		d.bottom.Store(b + 1)
+		return *new(T), ErrEmptyQueue
+	}
+
+	c := r.get(b)
+	if t == b {
+		// the last content, which a thief may be stealing as well.
+		won := d.top.CompareAndSwap(t, t+1)
+		d.bottom.Store(b + 1)
+		if !won {
+			return *new(T), ErrEmptyQueue
+		}
+	}
+	r.put(b, nil) // release the content for the GC
+	return *c, nil
+}
+
+// Steal removes the oldest content from the top of the deque. It may be called by any goroutine.
+// Returns ErrEmptyQueue if the deque is empty.
+func (d *WorkStealingDeque[T]) Steal() (T, error) {
+	for {
+		t := d.top.Load()
+		b := d.bottom.Load()
+		if t >= b {
+			return *new(T), ErrEmptyQueue
+		}
+		c := d.ring.Load().get(t)
+		if d.top.CompareAndSwap(t, t+1) {
+			return *c, nil
+		}
+		// another thief or the owner's Pop of the last content got t first.
+	}
+}
+
+// StealHalf steals half of the contents of the deque, rounded up, oldest first, so that a thief
+// can take a batch of work at once instead of coming back for every content. The contents are
+// stolen one by one like with Steal, since the owner pops without synchronization as long as it
+// doesn't reach the top, so other thieves and the owner may take some of them concurrently.
+// Returns an empty slice if the deque is empty.
+func (d *WorkStealingDeque[T]) StealHalf() []T {
+	n := (d.Len() + 1) / 2
+	stolen := make([]T, 0, n)
+	for len(stolen) < n {
+		c, err := d.Steal()
+		if err != nil {
+			break
+		}
+		stolen = append(stolen, c)
+	}
+	return stolen
+}
+
+// Len returns the number of contents in the deque. Under concurrent use it is only a snapshot.
+func (d *WorkStealingDeque[T]) Len() int {
+	// top is loaded first, so that bottom can't fall behind it by more than a Pop in progress.
+	t := d.top.Load()
+	b := d.bottom.Load()
+	if b < t {
+		return 0
+	}
+	return int(b - t)
+}
@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestWorkStealingDequeSequential(t *testing.T) {
+	t.Parallel()
+	d, err := NewWorkStealingDeque[int](2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// grows twice.
+	for i := 0; i < 8; i++ {
+		d.Push(i)
+	}
+	if d.Len() != 8 {
+		t.Errorf("expected length 8, got %d", d.Len())
+	}
+
+	if c, err := d.Steal(); err != nil || c != 0 {
+		t.Errorf("expected 0, got %d, %v", c, err)
+	}
+	if got, want := d.StealHalf(), []int{1, 2, 3, 4}; !equalContents(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for _, want := range []int{7, 6, 5} {
+		if c, err := d.Pop(); err != nil || c != want {
+			t.Errorf("expected %d, got %d, %v", want, c, err)
+		}
+	}
+	if _, err := d.Pop(); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+	if _, err := d.Steal(); !errors.Is(err, ErrEmptyQueue) {
+		t.Errorf("expected %v, got %v", ErrEmptyQueue, err)
+	}
+	if got := d.StealHalf(); len(got) != 0 {
+		t.Errorf("expected nothing, got %v", got)
+	}
+}
+
+func TestWorkStealingDequeConcurrent(t *testing.T) {
+	t.Parallel()
+	const thieves, n = 4, 5000
+	d, err := NewWorkStealingDeque[int](4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make([]atomic.Int32, n)
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	for i := 0; i < thieves; i++ {
+		wg.Add(1)
+		go func(half bool) {
+			defer wg.Done()
+			for !done.Load() || d.Len() > 0 {
+				if half {
+					for _, c := range d.StealHalf() {
+						counts[c].Add(1)
+					}
+				} else if c, err := d.Steal(); err == nil {
+					counts[c].Add(1)
+				}
+				runtime.Gosched()
+			}
+		}(i%2 == 0)
+	}
+
+	// the owner pops every third content itself.
+	for i := 0; i < n; i++ {
+		d.Push(i)
+		if i%3 == 0 {
+			if c, err := d.Pop(); err == nil {
+				counts[c].Add(1)
+			}
+		}
+	}
+	done.Store(true)
+	wg.Wait()
+
+	for c := range counts {
+		if got := counts[c].Load(); got != 1 {
+			t.Errorf("%d taken %d times", c, got)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package sorting
+
+// CompareChain combines the comparison functions cmps into a single comparison function. The
+// elements are compared by the first function; on ties the next function decides, and so on.
+// If all functions report a tie, the combined function returns 0.
+// The result can be passed to any function taking a comparison function, e.g. SortIndicesFunc or
+// slices.SortStableFunc.
+func CompareChain[T any](cmps ...func(a, b T) int) func(a, b T) int {
+	return func(a, b T) int {
+		for _, cmp := range cmps {
+			if c := cmp(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+}
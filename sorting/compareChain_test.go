@@ -0,0 +1,34 @@
+package sorting
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+type record struct {
+	name string
+	age  int
+}
+
+func TestCompareChain(t *testing.T) {
+	t.Parallel()
+	data := []record{{"b", 30}, {"a", 20}, {"b", 40}, {"c", 10}, {"a", 25}, {"b", 30}}
+	byName := func(a, b record) int { return cmp.Compare(a.name, b.name) }
+	byAgeDesc := func(a, b record) int { return cmp.Compare(b.age, a.age) }
+
+	slices.SortStableFunc(data, CompareChain(byName, byAgeDesc))
+
+	want := []record{{"a", 25}, {"a", 20}, {"b", 40}, {"b", 30}, {"b", 30}, {"c", 10}}
+	if !slices.Equal(data, want) {
+		t.Errorf("expected %v", want)
+		t.Errorf("     got %v", data)
+	}
+}
+
+func TestCompareChainEmpty(t *testing.T) {
+	t.Parallel()
+	if c := CompareChain[int]()(1, 2); c != 0 {
+		t.Errorf("expected 0, got %d", c)
+	}
+}
@@ -0,0 +1,27 @@
+package sorting
+
+import "cmp"
+
+// DetectRuns splits s into its maximal natural runs and returns the bounds [start, end) of each
+// run in order, as used by TimSort. A run is either non-descending or strictly descending; a
+// strictly descending run can be reversed into an ascending one without breaking stability.
+// Every run but the last one has at least two elements. NaNs are ordered before other floats, as in
+// cmp.Compare. Returns nil for an empty s.
+func DetectRuns[T cmp.Ordered](s []T) [][2]int {
+	var runs [][2]int
+	for start := 0; start < len(s); {
+		end := start + 1
+		if end < len(s) && cmp.Less(s[end], s[end-1]) {
+			for end < len(s) && cmp.Less(s[end], s[end-1]) {
+				end++
+			}
+		} else {
+			for end < len(s) && !cmp.Less(s[end], s[end-1]) {
+				end++
+			}
+		}
+		runs = append(runs, [2]int{start, end})
+		start = end
+	}
+	return runs
+}
@@ -0,0 +1,49 @@
+package sorting
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectRuns(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		s    []int
+		want [][2]int
+	}{
+		{"empty", nil, nil},
+		{"single", []int{4}, [][2]int{{0, 1}}},
+		{"sorted", []int{1, 2, 2, 3, 5, 8}, [][2]int{{0, 6}}},
+		{"reversed", []int{8, 5, 3, 2, 1}, [][2]int{{0, 5}}},
+		// equal neighbours end a descending run, since reversing them would break stability.
+		{"reversed with equal", []int{3, 2, 2, 1}, [][2]int{{0, 2}, {2, 4}}},
+		{"mixed", []int{1, 4, 9, 7, 3, 0, 5, 5, 6, 2}, [][2]int{{0, 3}, {3, 6}, {6, 9}, {9, 10}}},
+	}
+
+	for _, c := range cases {
+		if got := DetectRuns(c.s); !slices.Equal(got, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+	}
+
+	strs := []string{"a", "c", "b", "a"}
+	if got, want := DetectRuns(strs), [][2]int{{0, 2}, {2, 4}}; !slices.Equal(got, want) {
+		t.Errorf("strings: expected %v, got %v", want, got)
+	}
+}
+
+func TestDetectRunsCoverSlice(t *testing.T) {
+	t.Parallel()
+	runs := DetectRuns(ints)
+	next := 0
+	for _, r := range runs {
+		if r[0] != next || r[1] <= r[0] {
+			t.Fatalf("runs %v don't partition the slice", runs)
+		}
+		next = r[1]
+	}
+	if next != len(ints) {
+		t.Errorf("runs %v end at %d, expected %d", runs, next, len(ints))
+	}
+}
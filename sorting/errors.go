@@ -0,0 +1,8 @@
+package sorting
+
+import "errors"
+
+var (
+	// ErrLengthMismatch is returned when slices that need to have the same length don't.
+	ErrLengthMismatch = errors.New("slices differ in length")
+)
@@ -1,3 +1,3 @@
 module github.com/beeemT/Datastructures-and-Algorithms/sorting
 
-go 1.13
+go 1.21
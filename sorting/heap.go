@@ -0,0 +1,49 @@
+package sorting
+
+// heapPush pushes x onto the binary heap h ordered by less and returns the grown heap.
+func heapPush[T any](h []T, x T, less func(a, b T) bool) []T {
+	h = append(h, x)
+	heapUp(h, len(h)-1, less)
+	return h
+}
+
+// heapPop removes the top of the binary heap h ordered by less and returns it together with the
+// shrunk heap. h must not be empty.
+func heapPop[T any](h []T, less func(a, b T) bool) (T, []T) {
+	n := len(h) - 1
+	top := h[0]
+	h[0] = h[n]
+	h = h[:n]
+	heapDown(h, 0, less)
+	return top, h
+}
+
+func heapUp[T any](h []T, i int, less func(a, b T) bool) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !less(h[i], h[parent]) {
+			return
+		}
+		h[i], h[parent] = h[parent], h[i]
+		i = parent
+	}
+}
+
+func heapDown[T any](h []T, i int, less func(a, b T) bool) {
+	n := len(h)
+	for {
+		smallest := i
+		l, r := 2*i+1, 2*i+2
+		if l < n && less(h[l], h[smallest]) {
+			smallest = l
+		}
+		if r < n && less(h[r], h[smallest]) {
+			smallest = r
+		}
+		if smallest == i {
+			return
+		}
+		h[i], h[smallest] = h[smallest], h[i]
+		i = smallest
+	}
+}
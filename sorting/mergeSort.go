@@ -1,25 +1,44 @@
 package sorting
 
+import "cmp"
+
+// parallelMergeSortThreshold is the length from which MergeSort sorts the halves of a slice in
+// separate goroutines. Below it the goroutine overhead outweighs the gain.
+const parallelMergeSortThreshold = 4096
+
 func MergeSort(sort []int) []int {
 	if len(sort) <= 1 {
 		return sort
 	}
 
-	lS := len(sort) / 2
-	sortedL := make([]int, lS)
-	sortedR := make([]int, len(sort)-lS)
+	// scratch is shared by all recursion levels. Concurrent halves use disjoint parts of it.
+	scratch := make([]int, len(sort))
+	mergeSort(sort, scratch)
+	return sort
+}
 
-	if len(sort) > 2 {
-		retChan := make(chan []int)
+// mergeSort sorts sort in place, using scratch of the same length as temporary storage.
+func mergeSort(sort, scratch []int) {
+	if len(sort) <= 1 {
+		return
+	}
+
+	lS := len(sort) / 2
+	if len(sort) >= parallelMergeSortThreshold {
+		done := make(chan struct{})
 
-		go mergeSortChannel(sort[lS:], retChan)
-		copy(sortedL, MergeSort(sort[:lS]))
-		copy(sortedR, <-retChan)
+		go mergeSortChannel(sort[lS:], scratch[lS:], done)
+		mergeSort(sort[:lS], scratch[:lS])
+		<-done
 	} else {
-		sortedL[0] = sort[0]
-		sortedR[0] = sort[1]
+		mergeSort(sort[:lS], scratch[:lS])
+		mergeSort(sort[lS:], scratch[lS:])
 	}
 
+	copy(scratch, sort)
+	sortedL := scratch[:lS]
+	sortedR := scratch[lS:]
+
 	var iL, iR int
 	lR := len(sortedR)
 	lL := len(sortedL)
@@ -32,10 +51,66 @@ func MergeSort(sort []int) []int {
 			iR++
 		}
 	}
+}
 
-	return sort
+func mergeSortChannel(sort, scratch []int, done chan struct{}) {
+	mergeSort(sort, scratch)
+	close(done)
 }
 
-func mergeSortChannel(sort []int, retChan chan []int) {
-	retChan <- MergeSort(sort)
+// MergeSortDesc sorts s in descending order in place and returns it.
+// The merge step takes the larger element first, so the result is descending without a reversal
+// and equal elements keep their relative order. NaNs are ordered after other floats, as the reverse
+// of cmp.Compare.
+func MergeSortDesc[T cmp.Ordered](s []T) []T {
+	if len(s) <= 1 {
+		return s
+	}
+
+	scratch := make([]T, len(s))
+	mergeSortFunc(s, scratch, func(a, b T) int {
+		return cmp.Compare(b, a)
+	})
+	return s
+}
+
+// mergeSortFunc stably sorts sort in place as determined by cmp, using scratch of the same length
+// as temporary storage.
+func mergeSortFunc[T any](sort, scratch []T, cmp func(a, b T) int) {
+	if len(sort) <= 1 {
+		return
+	}
+
+	lS := len(sort) / 2
+	if len(sort) >= parallelMergeSortThreshold {
+		done := make(chan struct{})
+
+		go func() {
+			mergeSortFunc(sort[lS:], scratch[lS:], cmp)
+			close(done)
+		}()
+		mergeSortFunc(sort[:lS], scratch[:lS], cmp)
+		<-done
+	} else {
+		mergeSortFunc(sort[:lS], scratch[:lS], cmp)
+		mergeSortFunc(sort[lS:], scratch[lS:], cmp)
+	}
+
+	copy(scratch, sort)
+	sortedL := scratch[:lS]
+	sortedR := scratch[lS:]
+
+	var iL, iR int
+	lR := len(sortedR)
+	lL := len(sortedL)
+	for i := range sort {
+		// taking the left element on ties keeps the sort stable.
+		if (iL < lL) && (!(iR < lR) || cmp(sortedL[iL], sortedR[iR]) <= 0) {
+			sort[i] = sortedL[iL]
+			iL++
+		} else {
+			sort[i] = sortedR[iR]
+			iR++
+		}
+	}
 }
@@ -0,0 +1,20 @@
+package sorting
+
+import "cmp"
+
+// MergeSortViaHeap returns a new slice holding the elements of s in ascending order, s is left
+// unchanged. All elements are pushed onto a min-heap and popped off again in O(n log n), the same
+// way a k-way merge draws from its heap. The sort is not stable. NaNs are ordered before other
+// floats, as in cmp.Compare.
+func MergeSortViaHeap[T cmp.Ordered](s []T) []T {
+	h := make([]T, 0, len(s))
+	for _, x := range s {
+		h = heapPush(h, x, cmp.Less[T])
+	}
+
+	ret := make([]T, len(s))
+	for i := range ret {
+		ret[i], h = heapPop(h, cmp.Less[T])
+	}
+	return ret
+}
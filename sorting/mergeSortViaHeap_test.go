@@ -0,0 +1,65 @@
+package sorting
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestMergeSortViaHeap(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(42))
+	large := make([]int, 10000)
+	for i := range large {
+		large[i] = r.Intn(100)
+	}
+
+	for _, data := range [][]int{nil, {1}, {2, 1}, ints, large} {
+		orig := slices.Clone(data)
+		want := slices.Clone(data)
+		slices.Sort(want)
+
+		got := MergeSortViaHeap(data)
+		if !slices.Equal(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+		if !slices.Equal(data, orig) {
+			t.Errorf("input was modified: %v", data)
+		}
+	}
+
+	floats := []float64{2.5, math.NaN(), -1, 0}
+	got := MergeSortViaHeap(floats)
+	if !math.IsNaN(got[0]) || !slices.Equal(got[1:], []float64{-1, 0, 2.5}) {
+		t.Errorf("expected [NaN -1 0 2.5], got %v", got)
+	}
+}
+
+func BenchmarkMergeSortViaHeap(b *testing.B) {
+	data := make([]int, 10000)
+	r := rand.New(rand.NewSource(42))
+	for i := range data {
+		data[i] = r.Int()
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MergeSortViaHeap(data)
+	}
+}
+
+// BenchmarkSlicesSortClone is the in-place baseline, given its own copy to sort like
+// MergeSortViaHeap.
+func BenchmarkSlicesSortClone(b *testing.B) {
+	data := make([]int, 10000)
+	r := rand.New(rand.NewSource(42))
+	for i := range data {
+		data[i] = r.Int()
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		slices.Sort(slices.Clone(data))
+	}
+}
@@ -1,6 +1,8 @@
 package sorting
 
 import (
+	"math/rand"
+	"slices"
 	"sort"
 	"testing"
 )
@@ -15,3 +17,87 @@ func TestMergeSortIntSlice(t *testing.T) {
 		t.Errorf("   got %v", ret)
 	}
 }
+
+func TestMergeSortLargeIntSlice(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(42))
+	data := make([]int, 100000)
+	for i := range data {
+		data[i] = r.Intn(1000) - 500
+	}
+	ret := MergeSort(data)
+	if !sort.IsSorted(sort.IntSlice(ret)) {
+		t.Error("large slice is not sorted")
+	}
+}
+
+func TestMergeSortAllocs(t *testing.T) {
+	data := make([]int, 1000)
+	r := rand.New(rand.NewSource(42))
+	allocs := testing.AllocsPerRun(10, func() {
+		for i := range data {
+			data[i] = r.Int()
+		}
+		MergeSort(data)
+	})
+	// only the scratch buffer is allocated below the parallel threshold.
+	if allocs > 1 {
+		t.Errorf("expected 1 allocation, got %v", allocs)
+	}
+}
+
+func TestMergeSortDesc(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(42))
+
+	large := make([]int, 10000)
+	for i := range large {
+		large[i] = r.Intn(1000) - 500
+	}
+	for _, data := range [][]int{{}, {1}, append([]int(nil), ints...), large} {
+		want := append([]int(nil), data...)
+		sort.Sort(sort.Reverse(sort.IntSlice(want)))
+		if got := MergeSortDesc(data); !slices.Equal(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+
+	floats := []float64{0.5, -3, 2.25, 2.25, 1e9, -1e-9, 0}
+	wantF := []float64{1e9, 2.25, 2.25, 0.5, 0, -1e-9, -3}
+	if got := MergeSortDesc(floats); !slices.Equal(got, wantF) {
+		t.Errorf("expected %v, got %v", wantF, got)
+	}
+
+	strs := []string{"pear", "apple", "fig", "banana", "fig"}
+	wantS := []string{"pear", "fig", "fig", "banana", "apple"}
+	if got := MergeSortDesc(strs); !slices.Equal(got, wantS) {
+		t.Errorf("expected %v, got %v", wantS, got)
+	}
+}
+
+func TestMergeSortDescStable(t *testing.T) {
+	t.Parallel()
+	type record struct {
+		key, pos int
+	}
+	r := rand.New(rand.NewSource(7))
+	// long enough to take the parallel path.
+	data := make([]record, 2*parallelMergeSortThreshold)
+	for i := range data {
+		data[i] = record{key: r.Intn(10), pos: i}
+	}
+
+	// the same comparison MergeSortDesc runs on, applied to the keys only.
+	mergeSortFunc(data, make([]record, len(data)), func(a, b record) int {
+		return b.key - a.key
+	})
+	for i := 1; i < len(data); i++ {
+		prev, cur := data[i-1], data[i]
+		if prev.key < cur.key {
+			t.Fatalf("not descending at %d: %v before %v", i, prev, cur)
+		}
+		if prev.key == cur.key && prev.pos > cur.pos {
+			t.Fatalf("not stable at %d: %v before %v", i, prev, cur)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package sorting
+
+// Partition3 rearranges s in one pass into three regions around pivot, as in the Dutch national
+// flag problem: s[:lt] holds the elements less than pivot, s[lt:gt] the elements equal to it and
+// s[gt:] the elements greater than it. An element is equal to pivot if neither less nor greater
+// holds for it. The order within the regions is not preserved.
+func Partition3[T any](
+	s []T,
+	less func(a, pivot T) bool,
+	greater func(a, pivot T) bool,
+	pivot T,
+) (lt, gt int) {
+	lt, gt = 0, len(s)
+	for i := 0; i < gt; {
+		switch {
+		case less(s[i], pivot):
+			s[lt], s[i] = s[i], s[lt]
+			lt++
+			i++
+		case greater(s[i], pivot):
+			// the element swapped in from the back is unseen, so i stays.
+			gt--
+			s[i], s[gt] = s[gt], s[i]
+		default:
+			i++
+		}
+	}
+	return lt, gt
+}
@@ -0,0 +1,47 @@
+package sorting
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestPartition3(t *testing.T) {
+	t.Parallel()
+	less := func(a, pivot int) bool { return a < pivot }
+	greater := func(a, pivot int) bool { return a > pivot }
+
+	r := rand.New(rand.NewSource(42))
+	many := make([]int, 1000)
+	for i := range many {
+		// values around the pivot, half of them equal to it.
+		if i%2 == 0 {
+			many[i] = 5
+		} else {
+			many[i] = r.Intn(11)
+		}
+	}
+
+	for _, data := range [][]int{nil, {5}, {5, 5, 5}, {1, 2, 3}, {9, 8, 7}, {5, 1, 9, 5, 3, 5, 7}, many} {
+		orig := slices.Clone(data)
+		lt, gt := Partition3(data, less, greater, 5)
+
+		if lt < 0 || lt > gt || gt > len(data) {
+			t.Fatalf("%v: invalid bounds %d, %d", orig, lt, gt)
+		}
+		for i, x := range data {
+			switch {
+			case i < lt && x >= 5, i >= lt && i < gt && x != 5, i >= gt && x <= 5:
+				t.Errorf("%v: %d at %d is in the wrong region of [%d, %d) in %v", orig, x, i, lt, gt, data)
+			}
+		}
+
+		// partitioning must only permute s.
+		slices.Sort(orig)
+		sorted := slices.Clone(data)
+		slices.Sort(sorted)
+		if !slices.Equal(orig, sorted) {
+			t.Errorf("expected a permutation of %v, got %v", orig, data)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package sorting
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+)
+
+// ShuffleSortCheck randomly permutes s in place until it is sorted or maxIters permutations were
+// tried, and returns whether s ended up sorted. It is deliberately inefficient (O(n * n!) on
+// average) and meant as an independent sortedness oracle and source of random permutations in
+// tests. maxIters bounds the running time for anything but tiny slices.
+func ShuffleSortCheck[T cmp.Ordered](s []T, maxIters int) bool {
+	for i := 0; ; i++ {
+		if slices.IsSorted(s) {
+			return true
+		}
+		if i >= maxIters {
+			return false
+		}
+		rand.Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
+	}
+}
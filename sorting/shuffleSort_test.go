@@ -0,0 +1,36 @@
+package sorting
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestShuffleSortCheckTiny(t *testing.T) {
+	t.Parallel()
+	for _, data := range [][]int{{}, {1}, {2, 1}, {3, 1, 2}, {4, 2, 3, 1}} {
+		orig := slices.Clone(data)
+		if !ShuffleSortCheck(data, 100000) {
+			t.Errorf("did not sort %v within the cap", orig)
+		}
+		if !slices.IsSorted(data) {
+			t.Errorf("sorted %v", orig)
+			t.Errorf("   got %v", data)
+		}
+	}
+}
+
+func TestShuffleSortCheckCap(t *testing.T) {
+	t.Parallel()
+	data := make([]int, len(ints))
+	copy(data, ints)
+	if ShuffleSortCheck(data, 100) {
+		t.Errorf("expected %d elements not to be sorted within 100 shuffles", len(data))
+	}
+
+	slices.Sort(data)
+	want := slices.Clone(ints)
+	slices.Sort(want)
+	if !slices.Equal(data, want) {
+		t.Errorf("shuffles lost elements: %v", data)
+	}
+}
@@ -0,0 +1,24 @@
+package sorting
+
+import "cmp"
+
+// SortByKey sorts keys in ascending order and applies the same permutation to vals, so that
+// vals[i] still belongs to keys[i] afterwards. Equal keys keep their relative order.
+// Returns ErrLengthMismatch and leaves both slices untouched if their lengths differ.
+func SortByKey[K cmp.Ordered, V any](keys []K, vals []V) error {
+	if len(keys) != len(vals) {
+		return ErrLengthMismatch
+	}
+
+	perm := SortIndices(keys)
+	sortedKeys := make([]K, len(keys))
+	sortedVals := make([]V, len(vals))
+	for i, j := range perm {
+		sortedKeys[i] = keys[j]
+		sortedVals[i] = vals[j]
+	}
+	copy(keys, sortedKeys)
+	copy(vals, sortedVals)
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package sorting
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSortByKey(t *testing.T) {
+	t.Parallel()
+	keys := []int{3, 1, 2, 1, 0}
+	vals := []string{"three", "one", "two", "uno", "zero"}
+	if err := SortByKey(keys, vals); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{0, 1, 1, 2, 3}; !slices.Equal(keys, want) {
+		t.Errorf("expected keys %v, got %v", want, keys)
+	}
+	if want := []string{"zero", "one", "uno", "two", "three"}; !slices.Equal(vals, want) {
+		t.Errorf("expected values %v, got %v", want, vals)
+	}
+}
+
+func TestSortByKeyLengthMismatch(t *testing.T) {
+	t.Parallel()
+	keys := []int{2, 1}
+	vals := []string{"a"}
+	if err := SortByKey(keys, vals); !errors.Is(err, ErrLengthMismatch) {
+		t.Errorf("expected %v, got %v", ErrLengthMismatch, err)
+	}
+	if !slices.Equal(keys, []int{2, 1}) {
+		t.Errorf("expected keys to be untouched, got %v", keys)
+	}
+}
@@ -0,0 +1,29 @@
+package sorting
+
+import (
+	"cmp"
+	"slices"
+)
+
+// SortIndices returns the permutation of indices that would sort s in ascending order, without
+// modifying s. Equal elements keep their relative order. NaNs are ordered before other floats, as
+// in cmp.Compare.
+func SortIndices[T cmp.Ordered](s []T) []int {
+	return SortIndicesFunc(s, cmp.Compare[T])
+}
+
+// SortIndicesFunc returns the permutation of indices that would sort s in ascending order as
+// determined by cmp, without modifying s. cmp(a, b) must return a negative number when a < b, a
+// positive number when a > b and zero when a == b. Equal elements keep their relative order.
+func SortIndicesFunc[T any](s []T, cmp func(a, b T) int) []int {
+	idx := make([]int, len(s))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	slices.SortStableFunc(idx, func(i, j int) int {
+		return cmp(s[i], s[j])
+	})
+
+	return idx
+}
@@ -0,0 +1,63 @@
+package sorting
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSortIndicesIntSlice(t *testing.T) {
+	t.Parallel()
+	data := make([]int, len(ints))
+	copy(data, ints)
+	idx := SortIndices(data)
+
+	sorted := make([]int, len(idx))
+	for i, j := range idx {
+		sorted[i] = data[j]
+	}
+	if !sort.IsSorted(sort.IntSlice(sorted)) {
+		t.Errorf("sorted %v", ints)
+		t.Errorf("   got %v", sorted)
+	}
+
+	for i := range ints {
+		if data[i] != ints[i] {
+			t.Errorf("input was modified: %v", data)
+			break
+		}
+	}
+}
+
+func TestSortIndicesFuncStable(t *testing.T) {
+	t.Parallel()
+	data := []string{"b", "A", "a", "c", "B"}
+	idx := SortIndicesFunc(data, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+
+	want := []int{1, 2, 0, 4, 3}
+	for i := range want {
+		if idx[i] != want[i] {
+			t.Errorf("expected %v", want)
+			t.Errorf("     got %v", idx)
+			break
+		}
+	}
+}
+
+func TestSortIndicesNaN(t *testing.T) {
+	t.Parallel()
+	data := []float64{3, math.NaN(), 1, math.NaN(), 2}
+	idx := SortIndices(data)
+
+	sorted := make([]float64, len(idx))
+	for i, j := range idx {
+		sorted[i] = data[j]
+	}
+	if !sort.IsSorted(sort.Float64Slice(sorted)) {
+		t.Errorf("sorted %v", data)
+		t.Errorf("   got %v", sorted)
+	}
+}
@@ -0,0 +1,35 @@
+package sorting
+
+// SortNearlySorted sorts a in place, given that every element is at most k positions away from its
+// sorted position. A min-heap of size k+1 is slid over a, which takes O(n log k).
+// If an element is further than k positions away from its sorted position, a is not guaranteed to
+// be sorted afterwards.
+func SortNearlySorted(a []int, k int) {
+	if k < 0 {
+		k = 0
+	}
+	if k >= len(a) {
+		k = len(a) - 1
+	}
+	if len(a) <= 1 {
+		return
+	}
+
+	less := func(x, y int) bool { return x < y }
+	h := make([]int, 0, k+1)
+	for _, x := range a[:k+1] {
+		h = heapPush(h, x, less)
+	}
+
+	// the sorted element for position i is within a[:i+k+1] and all smaller ones are placed
+	// already, so it is the top of the heap holding the rest of that window.
+	for i := range a {
+		if i+k+1 < len(a) {
+			a[i] = h[0]
+			h[0] = a[i+k+1]
+			heapDown(h, 0, less)
+			continue
+		}
+		a[i], h = heapPop(h, less)
+	}
+}
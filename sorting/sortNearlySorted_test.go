@@ -0,0 +1,68 @@
+package sorting
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// nearlySorted returns n ascending ints where every element is at most k positions away from its
+// sorted position.
+func nearlySorted(r *rand.Rand, n, k int) []int {
+	data := make([]int, n)
+	for i := range data {
+		data[i] = i
+	}
+	for start := 0; start < n; start += k + 1 {
+		end := start + k + 1
+		if end > n {
+			end = n
+		}
+		block := data[start:end]
+		r.Shuffle(len(block), func(i, j int) { block[i], block[j] = block[j], block[i] })
+	}
+	return data
+}
+
+func TestSortNearlySortedIntSlice(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(42))
+	for _, k := range []int{0, 1, 3, 10, 1000} {
+		data := nearlySorted(r, 500, k)
+		SortNearlySorted(data, k)
+		if !sort.IsSorted(sort.IntSlice(data)) {
+			t.Errorf("k %d: got %v", k, data)
+		}
+	}
+
+	data := make([]int, len(ints))
+	copy(data, ints)
+	SortNearlySorted(data, len(data))
+	if !sort.IsSorted(sort.IntSlice(data)) {
+		t.Errorf("sorted %v", ints)
+		t.Errorf("   got %v", data)
+	}
+}
+
+func BenchmarkSortNearlySorted(b *testing.B) {
+	r := rand.New(rand.NewSource(42))
+	orig := nearlySorted(r, 100000, 8)
+	data := make([]int, len(orig))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(data, orig)
+		SortNearlySorted(data, 8)
+	}
+}
+
+func BenchmarkSortNearlySortedSlicesSort(b *testing.B) {
+	r := rand.New(rand.NewSource(42))
+	orig := nearlySorted(r, 100000, 8)
+	data := make([]int, len(orig))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(data, orig)
+		slices.Sort(data)
+	}
+}
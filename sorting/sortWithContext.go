@@ -0,0 +1,63 @@
+package sorting
+
+import (
+	"cmp"
+	"context"
+)
+
+// sortWithContextCheckLen is the length from which SortWithContext checks its context before
+// sorting a part of the slice. Smaller parts are sorted without interruption.
+const sortWithContextCheckLen = 1024
+
+// SortWithContext sorts s in ascending order in place with a merge sort that checks ctx before
+// every part of at least sortWithContextCheckLen elements it sorts. If ctx is done, it returns
+// ctx.Err() and leaves s partially sorted, s still holds the same elements.
+// Equal elements keep their relative order. NaNs are ordered before other floats, as in
+// cmp.Compare.
+func SortWithContext[T cmp.Ordered](ctx context.Context, s []T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(s) <= 1 {
+		return nil
+	}
+	return mergeSortContext(ctx, s, make([]T, len(s)))
+}
+
+// mergeSortContext sorts sort in place like mergeSortFunc, but stops at the next check of ctx once
+// it is done.
+func mergeSortContext[T cmp.Ordered](ctx context.Context, sort, scratch []T) error {
+	if len(sort) < sortWithContextCheckLen {
+		mergeSortFunc(sort, scratch, cmp.Compare[T])
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	lS := len(sort) / 2
+	if err := mergeSortContext(ctx, sort[:lS], scratch[:lS]); err != nil {
+		return err
+	}
+	if err := mergeSortContext(ctx, sort[lS:], scratch[lS:]); err != nil {
+		return err
+	}
+
+	copy(scratch, sort)
+	sortedL := scratch[:lS]
+	sortedR := scratch[lS:]
+
+	var iL, iR int
+	lR := len(sortedR)
+	lL := len(sortedL)
+	for i := range sort {
+		if (iL < lL) && (!(iR < lR) || cmp.Compare(sortedL[iL], sortedR[iR]) <= 0) {
+			sort[i] = sortedL[iL]
+			iL++
+		} else {
+			sort[i] = sortedR[iR]
+			iR++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package sorting
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cancelAfterContext reports itself as canceled once Err was called n times, to cancel a sort at a
+// deterministic point.
+type cancelAfterContext struct {
+	context.Context
+	n atomic.Int64
+}
+
+func (c *cancelAfterContext) Err() error {
+	if c.n.Add(-1) < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func randomInts(n int) []int {
+	r := rand.New(rand.NewSource(42))
+	s := make([]int, n)
+	for i := range s {
+		s[i] = r.Int()
+	}
+	return s
+}
+
+func TestSortWithContext(t *testing.T) {
+	t.Parallel()
+	for _, n := range []int{0, 1, 100, 100000} {
+		data := randomInts(n)
+		want := slices.Clone(data)
+		slices.Sort(want)
+
+		if err := SortWithContext(context.Background(), data); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(data, want) {
+			t.Errorf("length %d: not sorted", n)
+		}
+	}
+}
+
+func TestSortWithContextCancel(t *testing.T) {
+	t.Parallel()
+	data := randomInts(1 << 20)
+	orig := slices.Clone(data)
+	ctx := &cancelAfterContext{Context: context.Background()}
+	ctx.n.Store(10)
+
+	if err := SortWithContext(ctx, data); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if slices.IsSorted(data) {
+		t.Errorf("expected the sort to stop before the slice is sorted")
+	}
+	// the partially sorted slice still holds the same elements.
+	slices.Sort(orig)
+	slices.Sort(data)
+	if !slices.Equal(data, orig) {
+		t.Errorf("expected a permutation of the input")
+	}
+}
+
+func TestSortWithContextCancelPrompt(t *testing.T) {
+	t.Parallel()
+	// sorting this takes a few hundred milliseconds.
+	data := randomInts(1 << 22)
+	ctx, cancel := context.WithCancel(context.Background())
+	var canceled time.Time
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		canceled = time.Now()
+		cancel()
+	}()
+
+	err := SortWithContext(ctx, data)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	// a part of sortWithContextCheckLen elements sorts in well under a millisecond.
+	if d := time.Since(canceled); d > 50*time.Millisecond {
+		t.Errorf("expected a prompt return after cancel, took %v", d)
+	}
+}
@@ -0,0 +1,79 @@
+package sorting
+
+import "slices"
+
+// SortWithUndo stably sorts s in place as determined by less and returns a function that restores
+// the original order of s. Only the permutation applied to s is recorded, so undo is valid as long
+// as s is not modified in between. Calling undo more than once has no further effect.
+func SortWithUndo[T any](s []T, less func(a, b T) bool) (undo func()) {
+	// perm[i] is the original index of the element that is sorted to i.
+	perm := make([]int, len(s))
+	for i := range perm {
+		perm[i] = i
+	}
+	slices.SortStableFunc(perm, func(i, j int) int {
+		switch {
+		case less(s[i], s[j]):
+			return -1
+		case less(s[j], s[i]):
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	visited := make([]bool, len(s))
+	gather(s, perm, visited)
+
+	done := false
+	return func() {
+		if done {
+			return
+		}
+		done = true
+		clear(visited)
+		scatter(s, perm, visited)
+	}
+}
+
+// gather permutes s in place so that the element at perm[i] moves to i, following the cycles of
+// perm. visited must be all false and of the same length as s.
+func gather[T any](s []T, perm []int, visited []bool) {
+	for start := range s {
+		if visited[start] {
+			continue
+		}
+		tmp := s[start]
+		j := start
+		for {
+			visited[j] = true
+			k := perm[j]
+			if k == start {
+				s[j] = tmp
+				break
+			}
+			s[j] = s[k]
+			j = k
+		}
+	}
+}
+
+// scatter undoes gather: it permutes s in place so that the element at i moves to perm[i].
+// visited must be all false and of the same length as s.
+func scatter[T any](s []T, perm []int, visited []bool) {
+	for start := range s {
+		if visited[start] {
+			continue
+		}
+		x := s[start]
+		for j := start; ; {
+			visited[j] = true
+			k := perm[j]
+			x, s[k] = s[k], x
+			j = k
+			if j == start {
+				break
+			}
+		}
+	}
+}
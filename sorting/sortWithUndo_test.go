@@ -0,0 +1,53 @@
+package sorting
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestSortWithUndo(t *testing.T) {
+	t.Parallel()
+	type record struct {
+		key int
+		id  string
+	}
+	less := func(a, b record) bool { return a.key < b.key }
+
+	r := rand.New(rand.NewSource(42))
+	large := make([]record, 1000)
+	for i := range large {
+		// plenty of duplicate keys.
+		large[i] = record{key: r.Intn(20), id: string(rune('a' + i%26))}
+	}
+	cases := [][]record{
+		nil,
+		{{1, "a"}},
+		{{2, "a"}, {1, "b"}, {2, "c"}, {1, "d"}, {0, "e"}},
+		large,
+	}
+
+	for _, data := range cases {
+		orig := slices.Clone(data)
+		undo := SortWithUndo(data, less)
+
+		if !sort.SliceIsSorted(data, func(i, j int) bool { return less(data[i], data[j]) }) {
+			t.Errorf("not sorted: %v", data)
+		}
+		want := slices.Clone(orig)
+		slices.SortStableFunc(want, func(a, b record) int { return a.key - b.key })
+		if !slices.Equal(data, want) {
+			t.Errorf("expected the stable order %v, got %v", want, data)
+		}
+
+		undo()
+		if !slices.Equal(data, orig) {
+			t.Errorf("expected %v after undo, got %v", orig, data)
+		}
+		undo()
+		if !slices.Equal(data, orig) {
+			t.Errorf("expected a second undo to have no effect, got %v", data)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package sorting
+
+// Filter returns a new slice holding the elements of s for which pred returns true, in their
+// original order. It allocates at most once.
+func Filter[T any](s []T, pred func(T) bool) []T {
+	ret := make([]T, 0, len(s))
+	for _, x := range s {
+		if pred(x) {
+			ret = append(ret, x)
+		}
+	}
+	return ret
+}
+
+// Map returns a new slice holding f applied to every element of s.
+func Map[T, U any](s []T, f func(T) U) []U {
+	ret := make([]U, len(s))
+	for i, x := range s {
+		ret[i] = f(x)
+	}
+	return ret
+}
@@ -0,0 +1,34 @@
+package sorting
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+	even := func(x int) bool { return x%2 == 0 }
+	if got := Filter([]int{}, even); len(got) != 0 {
+		t.Errorf("expected empty slice, got %v", got)
+	}
+	if got := Filter([]int{2, 4, 6}, even); !slices.Equal(got, []int{2, 4, 6}) {
+		t.Errorf("expected all elements, got %v", got)
+	}
+	if got := Filter([]int{1, 3, 5}, even); len(got) != 0 {
+		t.Errorf("expected no elements, got %v", got)
+	}
+	if got := Filter(ints, even); !slices.Equal(got, []int{74, 238, -784, 0, 0, 42, 7586, -5467984, 7586}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestMap(t *testing.T) {
+	t.Parallel()
+	if got := Map([]int{}, strconv.Itoa); len(got) != 0 {
+		t.Errorf("expected empty slice, got %v", got)
+	}
+	if got := Map([]int{1, -2, 30}, strconv.Itoa); !slices.Equal(got, []string{"1", "-2", "30"}) {
+		t.Errorf("got %v", got)
+	}
+}